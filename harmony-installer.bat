@@ -30,7 +30,7 @@ if exist "%INSTALL_PATH%" (
 echo Building HarmonyLang...
 cd "%INSTALL_PATH%"
 go mod download
-go build -v -o "%HARMONY_BIN%\HarmonyLang.exe" "%INSTALL_PATH%\src\main.go"
+go build -v -o "%HARMONY_BIN%\HarmonyLang.exe" ./src
 
 if %ERRORLEVEL% NEQ 0 (
     echo Error: Build failed
@@ -41,25 +41,8 @@ if %ERRORLEVEL% NEQ 0 (
 echo Creating wrapper script...
 (
 echo @echo off
-echo IF "%%~1"=="run" ^(
-echo     IF NOT "%%~2"=="" ^(
-echo         IF EXIST "%%~2" ^(
-echo             "%HARMONY_BIN%\HarmonyLang.exe" "%%~2"
-echo         ^) ELSE ^(
-echo             echo Error: File %%~2 not found
-echo             exit /b 1
-echo         ^)
-echo     ^) ELSE ^(
-echo         echo Error: No file specified
-echo         exit /b 1
-echo     ^)
-echo ^) ELSE IF "%%~1"=="repl" ^(
-echo     "%HARMONY_BIN%\HarmonyLang.exe"
-echo ^) ELSE ^(
-echo     echo Usage:
-echo     echo   harmony run ^<file.harmony^>  - Run a Harmony source file
-echo     echo   harmony repl               - Start Harmony REPL
-echo ^)
+echo "%HARMONY_BIN%\HarmonyLang.exe" %%*
+echo exit /b %%ERRORLEVEL%%
 ) > "%HARMONY_BIN%\harmony.bat"
 
 :: Update PATH - both user and current session
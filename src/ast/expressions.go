@@ -136,10 +136,16 @@ type FunctionDeclarationExpression struct {
 
 func (FunctionDeclarationExpression) expression() {}
 
-type TryCatchExpression struct {
-	TryBlock        Expression
-	CatchBlock      Expression
+type CatchClause struct {
 	ErrorIdentifier string
+	ErrorType       Type
+	Body            Expression
+}
+
+type TryCatchExpression struct {
+	TryBlock     Expression
+	CatchClauses []CatchClause
+	FinallyBlock Expression
 }
 
 func (TryCatchExpression) expression() {}
@@ -163,3 +169,10 @@ type StructLiteralExpression struct {
 }
 
 func (StructLiteralExpression) expression() {}
+
+type ConstructorCallExpression struct {
+	Constructor Expression
+	Arguments   []Expression
+}
+
+func (ConstructorCallExpression) expression() {}
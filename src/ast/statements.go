@@ -39,6 +39,7 @@ type Parameter struct {
 
 type FunctionDeclarationStatment struct {
 	Identifier string
+	Receiver   *Parameter
 	Parameters []Parameter
 	Body       []Statement
 	ReturnType Type
@@ -54,6 +55,14 @@ type ImportStatement struct {
 
 func (ImportStatement) statement() {}
 
+// ExportStatement marks a top level declaration as part of the module's
+// public surface
+type ExportStatement struct {
+	Declaration Statement
+}
+
+func (ExportStatement) statement() {}
+
 type TraditionalForStatement struct {
 	Initializer Statement
 	Condition   Expression
@@ -100,6 +109,12 @@ type ThrowStatement struct {
 
 func (ThrowStatement) statement() {}
 
+type DeferStatement struct {
+	Value Expression
+}
+
+func (DeferStatement) statement() {}
+
 type TypeDeclarationStatement struct {
 	Identifier string
 	Type       Type
@@ -111,8 +126,17 @@ type StructProperty struct {
 	Identifier   string
 	Type         Type
 	DefaultValue Expression
+	Validator    Expression
 	IsStatic     bool
 	IsConst      bool
+	IsRequired   bool
+	Tags         []StructTag
+}
+
+// StructTag is metadata attached to a property, e.g. @json("id", omitempty)
+type StructTag struct {
+	Name      string
+	Arguments []string
 }
 
 type StructMethod struct {
@@ -122,6 +146,7 @@ type StructMethod struct {
 
 type StructDeclarationStatement struct {
 	Identifier string
+	Embedded   []string
 	Properties []StructProperty
 	Methods    []StructMethod
 }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/table-harmony/HarmonyLang/src/formatter"
+	"github.com/table-harmony/HarmonyLang/src/interpreter"
+	"github.com/table-harmony/HarmonyLang/src/packages"
+)
+
+const source_extension = ".harmony"
+
+// source_files expands the given paths into the source files ending with
+// suffix, walking directories but not their vendor trees or hidden
+// directories. Files named directly are always included.
+func source_files(paths []string, suffix string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files := make([]string, 0)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				name := entry.Name()
+				if file != path && (name == packages.VendorDir || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(file, suffix) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// run_check parses the given files and reports their syntax errors
+func run_check(args []string) int {
+	files, err := source_files(args, source_extension)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "harmony check: %v\n", err)
+		return 1
+	}
+
+	failed := 0
+	for _, file := range files {
+		source, err := read_source(file)
+		if err == nil {
+			_, err = interpreter.Parse(source)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// run_fmt formats the given files in place, or lists the files that are not
+// formatted when checking
+func run_fmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	check := flags.Bool("check", false, "list unformatted files instead of rewriting them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	files, err := source_files(flags.Args(), source_extension)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "harmony fmt: %v\n", err)
+		return 1
+	}
+
+	unformatted := 0
+	for _, file := range files {
+		source, err := read_source(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "harmony fmt: %v\n", err)
+			return 1
+		}
+
+		formatted := formatter.Format(source)
+		if formatted == source {
+			continue
+		}
+
+		unformatted++
+		fmt.Println(file)
+		if !*check {
+			if err := os.WriteFile(file, []byte(formatted), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "harmony fmt: %v\n", err)
+				return 1
+			}
+		}
+	}
+
+	if *check && unformatted > 0 {
+		return 1
+	}
+	return 0
+}
+
+// test_suffix ends the names of the files run by harmony test
+const test_suffix = "_test" + source_extension
+
+// run_test runs the tests of the given test files or of the test files in
+// the given directories
+func run_test(args []string) int {
+	files, err := source_files(args, test_suffix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "harmony test: %v\n", err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "harmony test: no %s files found\n", "*"+test_suffix)
+		return 1
+	}
+
+	passed, failed := 0, 0
+	for _, file := range files {
+		source, err := read_source(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "harmony test: %v\n", err)
+			return 1
+		}
+
+		program, err := interpreter.Parse(source)
+		if err == nil {
+			err = interpreter.RunTests(program, file, func(result interpreter.TestResult) {
+				if result.Err != nil {
+					failed++
+					fmt.Printf("FAIL %s %s (%v)\n     %v\n", file, result.Name, round(result.Duration), result.Err)
+				} else {
+					passed++
+					fmt.Printf("ok   %s %s (%v)\n", file, result.Name, round(result.Duration))
+				}
+			})
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL %s\n     %v\n", file, err)
+		}
+	}
+
+	fmt.Printf("\n%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func round(duration time.Duration) time.Duration {
+	return duration.Round(time.Microsecond)
+}
@@ -0,0 +1,136 @@
+package formatter
+
+import (
+	"strings"
+)
+
+// indent_unit is the indentation of one level of nesting
+const indent_unit = "  "
+
+// Format tidies the layout of Harmony source code. Lines are indented by
+// their nesting of braces, brackets and parentheses, trailing whitespace is
+// removed, runs of blank lines are collapsed into one and the file ends with
+// a single newline. Comments and the contents of strings are left as is.
+func Format(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	lines := make([]string, 0)
+	open := nesting{}
+	inString := false
+	pendingBlank := false
+	for _, line := range strings.Split(source, "\n") {
+		// A line that starts inside of a multiline string is part of it
+		if inString {
+			inString = scan_line(line, true, &open).inString
+			lines = append(lines, line)
+			continue
+		}
+
+		depth := open.depth()
+		trimmed := strings.TrimLeft(line, " \t")
+		scan := scan_line(trimmed, false, &open)
+		if !scan.inString {
+			trimmed = strings.TrimRight(trimmed, " \t")
+		}
+
+		if trimmed == "" {
+			pendingBlank = len(lines) > 0
+			continue
+		}
+		if pendingBlank {
+			lines = append(lines, "")
+			pendingBlank = false
+		}
+
+		level := depth - scan.leadingClosers
+		if strings.HasPrefix(trimmed, ".") {
+			level++
+		}
+		lines = append(lines, strings.Repeat(indent_unit, max(level, 0))+trimmed)
+		inString = scan.inString
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// nesting holds the open brackets, true for those that indent. Only the last
+// bracket a line leaves open indents, so `f(map{` adds a single level as it
+// does in gofmt.
+type nesting []bool
+
+func (n nesting) depth() int {
+	depth := 0
+	for _, indents := range n {
+		if indents {
+			depth++
+		}
+	}
+	return depth
+}
+
+type line_scan struct {
+	leadingClosers int
+	inString       bool
+}
+
+// scan_line opens and closes the brackets of a line, skipping strings and
+// comments. The leading closers are those that end an indent before any
+// other text of the line.
+func scan_line(line string, inString bool, open *nesting) line_scan {
+	result := line_scan{}
+	leading := !inString
+	opened := 0
+	defer func() {
+		if opened > 0 {
+			(*open)[len(*open)-1] = true
+		}
+	}()
+
+	for i := 0; i < len(line); i++ {
+		char := line[i]
+		if inString {
+			if char == '\\' {
+				i++
+			} else if char == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch char {
+		case '"':
+			inString = true
+			leading = false
+		case '/':
+			if i+1 < len(line) && line[i+1] == '/' {
+				return result
+			}
+			leading = false
+		case '{', '(', '[':
+			*open = append(*open, false)
+			opened++
+			leading = false
+		case '}', ')', ']':
+			if len(*open) == 0 {
+				continue
+			}
+			indents := (*open)[len(*open)-1]
+			*open = (*open)[:len(*open)-1]
+			if opened > 0 {
+				opened--
+			}
+			if leading && indents {
+				result.leadingClosers++
+			}
+		case ' ', '\t':
+		default:
+			leading = false
+		}
+	}
+
+	result.inString = inString
+	return result
+}
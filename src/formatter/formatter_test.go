@@ -0,0 +1,64 @@
+package formatter
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name, source, expected string
+	}{
+		{
+			"nested blocks",
+			"fn main() {\nif true {\nprintln(1)\n}\n}",
+			"fn main() {\n  if true {\n    println(1)\n  }\n}\n",
+		},
+		{
+			"one level for several openers on a line",
+			"const feed = xml.from_map(map{\n\"title\" -> \"News\",\n})\n",
+			"const feed = xml.from_map(map{\n  \"title\" -> \"News\",\n})\n",
+		},
+		{
+			"a closer followed by arguments",
+			"call(first, map{\n\"a\" -> 1,\n}, last)\n",
+			"call(first, map{\n  \"a\" -> 1,\n}, last)\n",
+		},
+		{
+			"openers on separate lines",
+			"call(\nmap{\n\"a\" -> 1,\n},\n)\n",
+			"call(\n  map{\n    \"a\" -> 1,\n  },\n)\n",
+		},
+		{
+			"brackets in strings and comments",
+			"let s = \"{(\" // [\nprintln(s)\n",
+			"let s = \"{(\" // [\nprintln(s)\n",
+		},
+		{
+			"escaped quotes",
+			"let s = \"a \\\" {\"\nprintln(s)\n",
+			"let s = \"a \\\" {\"\nprintln(s)\n",
+		},
+		{
+			"multiline strings are kept",
+			"fn f() {\nlet s = \"a\n   b {\"\nreturn s\n}\n",
+			"fn f() {\n  let s = \"a\n   b {\"\n  return s\n}\n",
+		},
+		{
+			"blank lines and trailing whitespace",
+			"\n\nlet a = 1   \n\n\n\nlet b = 2\n\n",
+			"let a = 1\n\nlet b = 2\n",
+		},
+		{
+			"method chains",
+			"items\n.map(f)\n.filter(g)\n",
+			"items\n  .map(f)\n  .filter(g)\n",
+		},
+	}
+
+	for _, test := range tests {
+		if formatted := Format(test.source); formatted != test.expected {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, formatted, test.expected)
+		}
+		if formatted := Format(test.expected); formatted != test.expected {
+			t.Errorf("%s: formatting is not stable, got\n%s", test.name, formatted)
+		}
+	}
+}
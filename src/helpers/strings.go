@@ -10,18 +10,35 @@ func Capitalize(input string) string {
 	return strings.ToUpper(string(input[0])) + input[1:]
 }
 
+// ProcessEscapes turns the escapes of a string literal, \n, \t, \r, \" and
+// \\, into the characters they stand for. Other backslashes are kept, so
+// patterns like \d reach the regex library as written.
 func ProcessEscapes(input string) string {
-	// First replace literal `\\` with a placeholder to prevent it from being confused with `\n`
-	placeholder := "\x00" // Temporary placeholder for `\\`
-	input = strings.ReplaceAll(input, `\\`, placeholder)
-
-	// Replace `\n`, `\t`, `\r` with their actual meanings
-	input = strings.ReplaceAll(input, `\n`, "\n") // Newline
-	input = strings.ReplaceAll(input, `\t`, "\t") // Tab
-	input = strings.ReplaceAll(input, `\r`, "\r") // Carriage return
+	if !strings.Contains(input, `\`) {
+		return input
+	}
 
-	// Restore literal `\\` from the placeholder
-	input = strings.ReplaceAll(input, placeholder, `\`)
+	var builder strings.Builder
+	for i := 0; i < len(input); i++ {
+		if input[i] != '\\' || i+1 == len(input) {
+			builder.WriteByte(input[i])
+			continue
+		}
 
-	return input
+		switch input[i+1] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 't':
+			builder.WriteByte('\t')
+		case 'r':
+			builder.WriteByte('\r')
+		case '"', '\\':
+			builder.WriteByte(input[i+1])
+		default:
+			builder.WriteByte('\\')
+			continue
+		}
+		i++
+	}
+	return builder.String()
 }
@@ -373,8 +373,6 @@ func (a *Array) Slice(start Value, end Value) Slice {
 	newSlice := Slice{
 		elements: &elements,
 		_type:    *NewSliceType(a._type.elementType),
-		length:   endIndex - startIndex,
-		capacity: a._type.size - startIndex,
 		methods:  make(map[string]NativeFunctionValue),
 	}
 	newSlice.init_methods()
@@ -0,0 +1,111 @@
+package interpreter
+
+import (
+	"fmt"
+)
+
+// assertion_failed throws an error with the assertion code. A message given
+// by the caller replaces the default one.
+func assertion_failed(args []Value, index int, message string, fields ...MapEntry) {
+	if len(args) > index {
+		message = format_value(args[index], false)
+	}
+	panic(NewThrowError(NewStructuredError(message, ErrorCodeAssertion, nil, fields)))
+}
+
+func init_assert_module() Module {
+	module := NewModule()
+
+	// ok(condition: bool, message?: string): nil
+	// Purpose: Fails unless the condition is true
+	module.exports["ok"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			if !args[0].(Boolean).Value() {
+				assertion_failed(args, 1, "expected the condition to be true")
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{BooleanType}, PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	// equal(actual: any, expected: any, message?: string): nil
+	// Purpose: Fails unless the values are equal by the rules of ==
+	module.exports["equal"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			actual, expected := args[0], args[1]
+			if !values_equal(actual, expected) {
+				assertion_failed(args, 2,
+					fmt.Sprintf("expected %s but got %s", format_value(expected, true), format_value(actual, true)),
+					MapEntry{NewString("actual"), actual},
+					MapEntry{NewString("expected"), expected},
+				)
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	// not_equal(actual: any, unexpected: any, message?: string): nil
+	// Purpose: Fails if the values are equal by the rules of ==
+	module.exports["not_equal"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			if values_equal(args[0], args[1]) {
+				assertion_failed(args, 2,
+					fmt.Sprintf("expected a value other than %s", format_value(args[1], true)),
+					MapEntry{NewString("actual"), args[0]},
+				)
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	// throws(callback: fn(), message?: string): any
+	// Purpose: Fails unless the callback throws, returns what it threw
+	module.exports["throws"] = NewVariadicNativeFunction(
+		func(args ...Value) (thrown Value) {
+			callback, ok := args[0].(Function)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "expected a callback function but got %v", args[0].Type())
+			}
+
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						if is_control_flow(r) {
+							panic(r)
+						}
+						thrown = exception_value(r)
+					}
+				}()
+
+				if _, err := callback.Call(); err != nil {
+					thrown = exception_value(err)
+				}
+			}()
+
+			if thrown == nil {
+				assertion_failed(args, 1, "expected the callback to throw")
+			}
+			return thrown
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
+
+	// fail(message: string): nil
+	// Purpose: Fails unconditionally
+	module.exports["fail"] = NewNativeFunction(
+		func(args ...Value) Value {
+			assertion_failed(args, 0, "")
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{NilType},
+	)
+
+	return *module
+}
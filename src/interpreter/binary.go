@@ -21,6 +21,11 @@ func evaluate_addition(left, right Value) Value {
 			return NewString(fmt.Sprintf("%v%v", left.Value(), right.Value()))
 		case String:
 			return NewString(left.Value() + right.Value())
+		case StructInstantiation:
+			if str, ok := right.to_string(); ok {
+				return NewString(left.Value() + str)
+			}
+			panic(fmt.Sprintf("cannot add values of type %v and %v", left.Type(), right.Type()))
 		default:
 			panic(fmt.Sprintf("cannot add values of type %v and %v", left.Type(), right.Type()))
 		}
@@ -160,6 +165,15 @@ func evaluate_greater_equals(left, right Value) Value {
 }
 
 func evaluate_equals(left, right Value) Value {
+	if left, ok := left.(StructInstantiation); ok {
+		right, ok := right.(StructInstantiation)
+		return NewBoolean(ok && struct_equals(left, right))
+	}
+	if left, ok := left.(ValueType); ok {
+		right, ok := right.(ValueType)
+		return NewBoolean(ok && left._type.Equals(right._type))
+	}
+
 	if left.Type() != right.Type() {
 		return NewBoolean(false)
 	}
@@ -174,11 +188,20 @@ func evaluate_equals(left, right Value) Value {
 	case Boolean:
 		right, _ := ExpectValue[Boolean](right)
 		return NewBoolean(left.Value() == right.Value())
-	case ValueType:
-		right, _ := ExpectValue[ValueType](right)
-		return NewBoolean(left._type.Equals(right._type))
 	case Nil:
 		return NewBoolean(true) // nil equals nil
+	case *Error:
+		return NewBoolean(left == right) // errors are compared by identity
+	case *XmlElement:
+		return NewBoolean(left == right) // elements are compared by identity
+	case *Stream:
+		return NewBoolean(left == right) // streams are compared by identity
+	case *File:
+		return NewBoolean(left == right) // files are compared by identity
+	case *Process:
+		return NewBoolean(left == right) // processes are compared by identity
+	case *Client:
+		return NewBoolean(left == right) // clients are compared by identity
 	default:
 		panic(fmt.Sprintf("cannot compare values of type %v", left.Type()))
 	}
@@ -0,0 +1,184 @@
+package interpreter
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"time"
+)
+
+// crypto_hashes maps the algorithm names accepted by the crypto module to
+// their hash constructors
+var crypto_hashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func crypto_hash(algorithm string) func() hash.Hash {
+	constructor, exists := crypto_hashes[algorithm]
+	if !exists {
+		throw_error(ErrorCodeInvalid, "unknown hash algorithm \"%s\", expected md5, sha1, sha256 or sha512", algorithm)
+	}
+	return constructor
+}
+
+// crypto_encode renders a digest as hex unless the optional encoding
+// argument asks for "base64" or "raw" bytes
+func crypto_encode(sum []byte, args []Value) Value {
+	encoding := "hex"
+	if len(args) > 0 {
+		str, ok := args[0].(String)
+		if !ok {
+			panic(fmt.Sprintf("digest encoding must be a string but got %v", args[0].Type()))
+		}
+		encoding = str.Value()
+	}
+
+	switch encoding {
+	case "hex":
+		return NewString(hex.EncodeToString(sum))
+	case "base64":
+		return NewString(base64.StdEncoding.EncodeToString(sum))
+	case "raw":
+		return NewString(string(sum))
+	default:
+		throw_error(ErrorCodeInvalid, "unknown digest encoding \"%s\", expected hex, base64 or raw", encoding)
+		return nil
+	}
+}
+
+func crypto_random_bytes(count int) []byte {
+	bytes := make([]byte, count)
+	if _, err := rand.Read(bytes); err != nil {
+		throw_native_error("crypto.random_bytes", err)
+	}
+	return bytes
+}
+
+// crypto_uuid formats 16 bytes as a UUID after setting its version and the
+// RFC 9562 variant bits
+func crypto_uuid(bytes []byte, version byte) string {
+	bytes[6] = bytes[6]&0x0f | version<<4
+	bytes[8] = bytes[8]&0x3f | 0x80
+
+	text := hex.EncodeToString(bytes)
+	return text[0:8] + "-" + text[8:12] + "-" + text[12:16] + "-" + text[16:20] + "-" + text[20:32]
+}
+
+func init_crypto_module() Module {
+	module := NewModule()
+
+	digest := func(algorithm string) *NativeFunctionValue {
+		return NewVariadicNativeFunction(
+			func(args ...Value) Value {
+				hash := crypto_hashes[algorithm]()
+				hash.Write([]byte(args[0].(String).Value()))
+				return crypto_encode(hash.Sum(nil), args[1:])
+			},
+			[]Type{PrimitiveType{StringType}, PrimitiveType{StringType}},
+			PrimitiveType{StringType},
+		)
+	}
+
+	// md5(data: string, encoding?: string): string
+	// Purpose: Returns the MD5 digest of data, as hex unless encoding is "base64" or "raw"
+	module.exports["md5"] = digest("md5")
+
+	// sha1(data: string, encoding?: string): string
+	// Purpose: Returns the SHA-1 digest of data
+	module.exports["sha1"] = digest("sha1")
+
+	// sha256(data: string, encoding?: string): string
+	// Purpose: Returns the SHA-256 digest of data
+	module.exports["sha256"] = digest("sha256")
+
+	// sha512(data: string, encoding?: string): string
+	// Purpose: Returns the SHA-512 digest of data
+	module.exports["sha512"] = digest("sha512")
+
+	// hmac(algorithm: string, key: string, data: string, encoding?: string): string
+	// Purpose: Returns the HMAC of data with the given key and hash algorithm
+	module.exports["hmac"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			mac := hmac.New(crypto_hash(args[0].(String).Value()), []byte(args[1].(String).Value()))
+			mac.Write([]byte(args[2].(String).Value()))
+			return crypto_encode(mac.Sum(nil), args[3:])
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{StringType}, PrimitiveType{StringType}, PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// equal(a: string, b: string): boolean
+	// Purpose: Compares two strings in constant time, for checking signatures without leaking timing
+	module.exports["equal"] = NewNativeFunction(
+		func(args ...Value) Value {
+			a := []byte(args[0].(String).Value())
+			b := []byte(args[1].(String).Value())
+			return NewBoolean(subtle.ConstantTimeCompare(a, b) == 1)
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{StringType}},
+		PrimitiveType{BooleanType},
+	)
+
+	// crc32(data: string): number
+	// Purpose: Returns the IEEE CRC-32 checksum of data
+	module.exports["crc32"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewNumber(float64(crc32.ChecksumIEEE([]byte(args[0].(String).Value()))))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{NumberType},
+	)
+
+	// random_bytes(count: number): string
+	// Purpose: Returns count cryptographically secure random bytes, encode them with the encoding module to print them
+	module.exports["random_bytes"] = NewNativeFunction(
+		func(args ...Value) Value {
+			count := args[0].(Number)
+			if !count.IsInteger() || count.Value() < 0 {
+				throw_error(ErrorCodeInvalid, "random_bytes expects a non-negative integer but got %v", count)
+			}
+			return NewString(string(crypto_random_bytes(int(count.Value()))))
+		},
+		[]Type{PrimitiveType{NumberType}},
+		PrimitiveType{StringType},
+	)
+
+	// uuid_v4(): string
+	// Purpose: Returns a random version 4 UUID
+	module.exports["uuid_v4"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(crypto_uuid(crypto_random_bytes(16), 4))
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	// uuid_v7(): string
+	// Purpose: Returns a version 7 UUID, which starts with the current Unix time in milliseconds so that later UUIDs sort after earlier ones
+	module.exports["uuid_v7"] = NewNativeFunction(
+		func(args ...Value) Value {
+			bytes := crypto_random_bytes(16)
+			var timestamp [8]byte
+			binary.BigEndian.PutUint64(timestamp[:], uint64(time.Now().UnixMilli()))
+			copy(bytes[0:6], timestamp[2:8])
+			return NewString(crypto_uuid(bytes, 7))
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	return *module
+}
@@ -0,0 +1,324 @@
+package interpreter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+type csv_options struct {
+	delimiter rune
+	comment   rune
+	headers   bool
+	trim      bool
+	columns   []string
+	crlf      bool
+}
+
+func csv_parse_options(options Value) csv_options {
+	result := csv_options{delimiter: ',', headers: true}
+	if options == nil {
+		return result
+	}
+
+	opts, ok := options.(Map)
+	if !ok {
+		panic(fmt.Sprintf("csv options must be a map but got %v", options.Type()))
+	}
+
+	char := func(name string, value Value) rune {
+		str := expect_option[String]("csv", name, value).Value()
+		if utf8.RuneCountInString(str) != 1 {
+			panic(fmt.Sprintf("csv option '%s' must be a single character but got \"%s\"", name, str))
+		}
+		char, _ := utf8.DecodeRuneInString(str)
+		return char
+	}
+
+	for _, entry := range *opts.entries {
+		switch name := entry.key.String(); name {
+		case "delimiter":
+			result.delimiter = char(name, entry.value)
+		case "comment":
+			result.comment = char(name, entry.value)
+		case "headers":
+			result.headers = expect_option[Boolean]("csv", name, entry.value).Value()
+		case "trim":
+			result.trim = expect_option[Boolean]("csv", name, entry.value).Value()
+		case "crlf":
+			result.crlf = expect_option[Boolean]("csv", name, entry.value).Value()
+		case "columns":
+			for _, column := range elements_of(entry.value) {
+				result.columns = append(result.columns, format_value(column, false))
+			}
+		default:
+			panic(fmt.Sprintf("unknown csv option: %s", name))
+		}
+	}
+	return result
+}
+
+// csv_reader reads records one at a time, turning them into maps keyed by
+// the header row when headers are enabled
+type csv_reader struct {
+	reader  *csv.Reader
+	options csv_options
+	header  []string
+}
+
+func new_csv_reader(source io.Reader, options csv_options) *csv_reader {
+	reader := csv.NewReader(source)
+	reader.Comma = options.delimiter
+	reader.Comment = options.comment
+	reader.TrimLeadingSpace = options.trim
+	reader.ReuseRecord = false
+
+	result := &csv_reader{reader: reader, options: options}
+	if options.headers {
+		result.header = options.columns
+		if result.header == nil {
+			header, err := reader.Read()
+			if err != nil && err != io.EOF {
+				throw_native_error("CSV parse error", err)
+			}
+			result.header = header
+		}
+
+		seen := make(map[string]bool)
+		for _, column := range result.header {
+			if seen[column] {
+				throw_error(ErrorCodeInvalid, "csv header has a duplicate column \"%s\"", column)
+			}
+			seen[column] = true
+		}
+	}
+	return result
+}
+
+// next returns the next row and the line it starts on, or nil at the end of
+// the input
+func (r *csv_reader) next() (Value, int) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, 0
+	}
+	if err != nil {
+		throw_native_error("CSV parse error", err)
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	if !r.options.headers {
+		fields := make([]Value, len(record))
+		for i, field := range record {
+			fields[i] = NewString(field)
+		}
+		return NewSlice(fields, PrimitiveType{StringType}), line
+	}
+
+	if len(record) != len(r.header) {
+		panic(NewThrowError(NewStructuredError(
+			fmt.Sprintf("CSV parse error: record on line %d has %d fields but the header has %d", line, len(record), len(r.header)),
+			ErrorCodeSyntax, nil, []MapEntry{{NewString("line"), NewNumber(float64(line))}},
+		)))
+	}
+
+	entries := make([]MapEntry, len(record))
+	for i, field := range record {
+		entries[i] = MapEntry{NewString(r.header[i]), NewString(field)}
+	}
+	return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{StringType}), line
+}
+
+func (r *csv_reader) row_type() Type {
+	if r.options.headers {
+		return NewMapType(PrimitiveType{StringType}, PrimitiveType{StringType})
+	}
+	return NewSliceType(PrimitiveType{StringType})
+}
+
+// read_all collects the remaining rows
+func (r *csv_reader) read_all() Value {
+	rows := make([]Value, 0)
+	for {
+		row, _ := r.next()
+		if row == nil {
+			break
+		}
+		rows = append(rows, row)
+	}
+	return NewSlice(rows, r.row_type())
+}
+
+// each hands the remaining rows to a callback along with their line number.
+// The callback stops the iteration by returning false.
+func (r *csv_reader) each(callback Function) {
+	for {
+		row, line := r.next()
+		if row == nil {
+			return
+		}
+
+		args := []Value{row, NewNumber(float64(line))}
+		result, err := callback.Call(args[:min(function_arity(callback), len(args))]...)
+		if err != nil {
+			panic(err)
+		}
+		if stop, ok := result.(Boolean); ok && !stop.Value() {
+			return
+		}
+	}
+}
+
+// csv_stringify writes rows of maps or slices as CSV. The columns of map rows
+// are the keys in the order they first appear unless given in the options,
+// and a header row is written unless disabled.
+func csv_stringify(rows Value, options csv_options) string {
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+	writer.Comma = options.delimiter
+	writer.UseCRLF = options.crlf
+
+	records := elements_of(rows)
+	columns := options.columns
+	if columns == nil {
+		seen := make(map[string]bool)
+		for _, record := range records {
+			row, ok := record.(Map)
+			if !ok {
+				continue
+			}
+			for _, entry := range *row.entries {
+				if key := format_value(entry.key, false); !seen[key] {
+					seen[key] = true
+					columns = append(columns, key)
+				}
+			}
+		}
+	}
+
+	write := func(fields []string) {
+		if err := writer.Write(fields); err != nil {
+			throw_native_error("CSV write error", err)
+		}
+	}
+
+	if options.headers && len(columns) > 0 {
+		write(columns)
+	}
+
+	for i, record := range records {
+		fields := make([]string, 0)
+		switch row := record.(type) {
+		case Map:
+			for _, column := range columns {
+				fields = append(fields, csv_field(row.Get(NewString(column))))
+			}
+		case Slice, Array:
+			for _, field := range elements_of(row) {
+				fields = append(fields, csv_field(field))
+			}
+		default:
+			throw_error(ErrorCodeInvalid, "csv row %d must be a map or a slice but got %v", i, record.Type())
+		}
+		write(fields)
+	}
+
+	writer.Flush()
+	return builder.String()
+}
+
+func csv_field(value Value) string {
+	if _, ok := value.(Nil); ok {
+		return ""
+	}
+	return format_value(value, false)
+}
+
+func init_csv_module() Module {
+	module := NewModule()
+
+	options := func(args []Value, index int) csv_options {
+		if len(args) > index {
+			return csv_parse_options(args[index])
+		}
+		return csv_parse_options(nil)
+	}
+
+	// parse(text: string, options?: map): []map[string -> string] | [][]string
+	// Purpose: Parses CSV text into rows, keyed by the header row unless headers are disabled
+	module.exports["parse"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			source := strings.NewReader(args[0].(String).Value())
+			return new_csv_reader(source, options(args, 1)).read_all()
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
+
+	// stringify(rows: []any, options?: map): string
+	// Purpose: Writes rows of maps or slices as CSV text
+	module.exports["stringify"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			return NewString(csv_stringify(args[0], options(args, 1)))
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{StringType},
+	)
+
+	// read_file(path: string, options?: map): []map[string -> string] | [][]string
+	// Purpose: Reads all rows of a CSV file
+	module.exports["read_file"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			file, err := os.Open(args[0].(String).Value())
+			if err != nil {
+				throw_native_error("", err)
+			}
+			defer file.Close()
+
+			return new_csv_reader(file, options(args, 1)).read_all()
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
+
+	// each_row(path: string, callback: fn(row, line), options?: map): nil
+	// Purpose: Streams the rows of a CSV file to a callback one at a time, returning false from the callback stops reading
+	module.exports["each_row"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			callback, ok := args[1].(Function)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "expected a callback function but got %v", args[1].Type())
+			}
+
+			file, err := os.Open(args[0].(String).Value())
+			if err != nil {
+				throw_native_error("", err)
+			}
+			defer file.Close()
+
+			new_csv_reader(file, options(args, 2)).each(callback)
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	// write_file(path: string, rows: []any, options?: map): nil
+	// Purpose: Writes rows of maps or slices to a CSV file
+	module.exports["write_file"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			content := csv_stringify(args[1], options(args, 2))
+			if err := os.WriteFile(args[0].(String).Value(), []byte(content), 0644); err != nil {
+				throw_native_error("", err)
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	return *module
+}
@@ -0,0 +1,86 @@
+package interpreter
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// base64_decode decodes text with or without padding
+func base64_decode(text string, encoding *base64.Encoding) Value {
+	decoded, err := encoding.WithPadding(base64.NoPadding).DecodeString(strings.TrimRight(text, "="))
+	if err != nil {
+		throw_error(ErrorCodeInvalid, "base64: %s", err.Error())
+	}
+	return NewString(string(decoded))
+}
+
+func init_encoding_module() Module {
+	module := NewModule()
+
+	// base64_encode(data: string): string
+	// Purpose: Encodes data with the standard base64 alphabet and padding
+	module.exports["base64_encode"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(base64.StdEncoding.EncodeToString([]byte(args[0].(String).Value())))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// base64_decode(text: string): string
+	// Purpose: Decodes standard base64, padding is optional
+	module.exports["base64_decode"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return base64_decode(args[0].(String).Value(), base64.StdEncoding)
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// base64url_encode(data: string): string
+	// Purpose: Encodes data with the URL and file name safe base64 alphabet without padding, as used by JWTs
+	module.exports["base64url_encode"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(base64.RawURLEncoding.EncodeToString([]byte(args[0].(String).Value())))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// base64url_decode(text: string): string
+	// Purpose: Decodes URL safe base64, padding is optional
+	module.exports["base64url_decode"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return base64_decode(args[0].(String).Value(), base64.URLEncoding)
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// hex_encode(data: string): string
+	// Purpose: Encodes data as lowercase hexadecimal
+	module.exports["hex_encode"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(hex.EncodeToString([]byte(args[0].(String).Value())))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// hex_decode(text: string): string
+	// Purpose: Decodes hexadecimal in either case
+	module.exports["hex_decode"] = NewNativeFunction(
+		func(args ...Value) Value {
+			decoded, err := hex.DecodeString(args[0].(String).Value())
+			if err != nil {
+				throw_error(ErrorCodeInvalid, "hex: %s", err.Error())
+			}
+			return NewString(string(decoded))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	return *module
+}
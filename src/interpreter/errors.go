@@ -1,5 +1,17 @@
 package interpreter
 
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+)
+
 type BreakError struct{}
 
 func (BreakError) Error() string { return "no enclosing block out of which to break" }
@@ -25,3 +37,283 @@ type ThrowError struct {
 func (v ThrowError) Error() string         { return v.value.String() }
 func NewThrowError(value Value) ThrowError { return ThrowError{value} }
 func (t ThrowError) Value() Value          { return t.value }
+
+// exception_identifier names the binding through which a bare throw finds the
+// exception handled by the enclosing catch clause. It is not a valid identifier
+// so it can never clash with user code.
+const exception_identifier = "@exception"
+
+// is_control_flow reports whether a recovered panic is a break, continue or
+// return unwinding the stack rather than an exception
+func is_control_flow(r any) bool {
+	switch r.(type) {
+	case BreakError, ContinueError, ReturnError:
+		return true
+	default:
+		return false
+	}
+}
+
+// exception_value converts a recovered panic into the value seen by a catch
+// clause. Thrown values are preserved, runtime failures become errors.
+func exception_value(r any) Value {
+	switch e := r.(type) {
+	case ThrowError:
+		return e.Value()
+	case error:
+		return NewError(e.Error())
+	case string:
+		return NewError(e)
+	default:
+		return NewError(fmt.Sprintf("%v", e))
+	}
+}
+
+// Error codes attached to the errors thrown by native functions
+const (
+	ErrorCodeNotFound         = "not_found"
+	ErrorCodeAlreadyExists    = "already_exists"
+	ErrorCodePermissionDenied = "permission_denied"
+	ErrorCodeTimeout          = "timeout"
+	ErrorCodeNetwork          = "network"
+	ErrorCodeSyntax           = "syntax"
+	ErrorCodeInvalid          = "invalid"
+	ErrorCodeIO               = "io"
+	ErrorCodeAssertion        = "assertion"
+	ErrorCodeProcess          = "process"
+)
+
+// NewNativeError converts a Go error into a structured error value. The code
+// and fields are derived from the error, the message is prefixed by context.
+func NewNativeError(context string, err error) *Error {
+	code := ErrorCodeIO
+	fields := make([]MapEntry, 0)
+	field := func(key string, value Value) {
+		fields = append(fields, MapEntry{key: NewString(key), value: value})
+	}
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, exec.ErrNotFound):
+		code = ErrorCodeNotFound
+	case errors.Is(err, fs.ErrExist):
+		code = ErrorCodeAlreadyExists
+	case errors.Is(err, fs.ErrPermission):
+		code = ErrorCodePermissionDenied
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		code = ErrorCodeTimeout
+	}
+
+	var pathErr *fs.PathError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var csvErr *csv.ParseError
+	var netErr net.Error
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &pathErr):
+		field("op", NewString(pathErr.Op))
+		field("path", NewString(pathErr.Path))
+	case errors.As(err, &syntaxErr):
+		code = ErrorCodeSyntax
+		field("offset", NewNumber(float64(syntaxErr.Offset)))
+	case errors.As(err, &typeErr):
+		code = ErrorCodeInvalid
+		field("offset", NewNumber(float64(typeErr.Offset)))
+	case errors.As(err, &csvErr):
+		code = ErrorCodeSyntax
+		field("line", NewNumber(float64(csvErr.Line)))
+		field("column", NewNumber(float64(csvErr.Column)))
+	case errors.As(err, &urlErr):
+		field("op", NewString(urlErr.Op))
+		field("url", NewString(urlErr.URL))
+		code = ErrorCodeNetwork
+		if urlErr.Timeout() {
+			code = ErrorCodeTimeout
+		}
+	case errors.As(err, &netErr):
+		code = ErrorCodeNetwork
+		if netErr.Timeout() {
+			code = ErrorCodeTimeout
+		}
+	}
+
+	message := err.Error()
+	if context != "" {
+		message = context + ": " + message
+	}
+	return NewStructuredError(message, code, nil, fields)
+}
+
+// throw_native_error throws a Go error as a catchable structured error
+func throw_native_error(context string, err error) {
+	panic(NewThrowError(NewNativeError(context, err)))
+}
+
+// throw_error throws a structured error with the given code
+func throw_error(code string, format string, args ...any) {
+	panic(NewThrowError(NewStructuredError(fmt.Sprintf(format, args...), code, nil, nil)))
+}
+
+// error_chain returns the error followed by the causes it wraps
+func error_chain(value Value) []Value {
+	chain := make([]Value, 0)
+	for value != nil {
+		if _, ok := value.(Nil); ok {
+			break
+		}
+		chain = append(chain, value)
+
+		err, ok := value.(*Error)
+		if !ok {
+			break
+		}
+		value = err.cause
+	}
+	return chain
+}
+
+// error_is reports whether any error in the chain is the target. A target
+// string matches errors by code, an error matches by identity or by code.
+func error_is(value Value, target Value) bool {
+	for _, current := range error_chain(value) {
+		err, ok := current.(*Error)
+		switch target := target.(type) {
+		case String:
+			if ok && err.code == target.Value() {
+				return true
+			}
+		case *Error:
+			if ok && (err == target || (target.code != "" && err.code == target.code)) {
+				return true
+			}
+		default:
+			if current == target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// error_as returns the first value in the chain of the given type or nil. The
+// type is either a struct declaration or the name of a type.
+func error_as(value Value, target Value) Value {
+	for _, current := range error_chain(value) {
+		switch target := target.(type) {
+		case *Struct:
+			if target.Type().Equals(current.Type()) {
+				return current
+			}
+		case String:
+			if current.Type().String() == target.Value() {
+				return current
+			}
+		default:
+			panic(fmt.Sprintf("expected a struct or a type name but got %v", target.Type()))
+		}
+	}
+	return NewNil()
+}
+
+// error_options builds an error from a message and an optional map with the
+// keys code, cause and fields
+func error_options(message string, options Value) *Error {
+	var code string
+	var cause Value
+	fields := make([]MapEntry, 0)
+
+	if options != nil {
+		opts, ok := options.(Map)
+		if !ok {
+			panic(fmt.Sprintf("error options must be a map but got %v", options.Type()))
+		}
+
+		for _, entry := range *opts.entries {
+			switch entry.key.String() {
+			case "code":
+				code = entry.value.String()
+			case "cause":
+				cause = entry.value
+			case "fields":
+				data, ok := entry.value.(Map)
+				if !ok {
+					panic(fmt.Sprintf("error fields must be a map but got %v", entry.value.Type()))
+				}
+				for _, field := range *data.entries {
+					fields = append(fields, MapEntry{key: NewString(field.key.String()), value: field.value})
+				}
+			default:
+				panic(fmt.Sprintf("unknown error option: %s", entry.key.String()))
+			}
+		}
+	}
+
+	return NewStructuredError(message, code, cause, fields)
+}
+
+func init_errors_module() Module {
+	module := NewModule()
+
+	// new(message: string, options?: map[string -> any]): error
+	// Purpose: Creates an error, options may set its code, cause and fields
+	module.exports["new"] = native_error
+
+	// wrap(err: any, context: string): error
+	// Purpose: Creates an error that adds context to an existing error, keeping its code
+	module.exports["wrap"] = NewNativeFunction(
+		func(args ...Value) Value {
+			code := ""
+			if err, ok := args[0].(*Error); ok {
+				code = err.code
+			}
+			return NewStructuredError(args[1].(String).Value(), code, args[0], nil)
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}},
+		PrimitiveType{ErrorType},
+	)
+
+	// unwrap(err: any): any
+	// Purpose: Returns the cause of an error or nil
+	module.exports["unwrap"] = NewNativeFunction(
+		func(args ...Value) Value {
+			if err, ok := args[0].(*Error); ok && err.cause != nil {
+				return err.cause
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
+
+	// is(err: any, target: error | string): bool
+	// Purpose: Reports whether the error or any of its causes matches the target error or code
+	module.exports["is"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewBoolean(error_is(args[0], args[1]))
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{BooleanType},
+	)
+
+	// as(err: any, type: struct | string): any
+	// Purpose: Returns the first error in the chain of the given type or nil
+	module.exports["as"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return error_as(args[0], args[1])
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
+
+	// chain(err: any): []any
+	// Purpose: Returns the error followed by every cause it wraps
+	module.exports["chain"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewSlice(error_chain(args[0]), PrimitiveType{AnyType})
+		},
+		[]Type{PrimitiveType{AnyType}},
+		NewSliceType(PrimitiveType{AnyType}),
+	)
+
+	return *module
+}
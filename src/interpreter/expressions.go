@@ -74,6 +74,9 @@ func evaluate_prefix_expression(expression ast.Expression, scope *Scope) Value {
 	}
 
 	right := evaluate_expression(expectedExpression.Right, scope)
+	if ref, ok := right.(Reference); ok {
+		right = ref.Load()
+	}
 
 	switch expectedExpression.Operator.Kind {
 	case lexer.NOT:
@@ -132,12 +135,16 @@ func evaluate_binary_expression(expression ast.Expression, scope *Scope) Value {
 	right := evaluate_expression(expectedExpression.Right, scope)
 	left := evaluate_expression(expectedExpression.Left, scope)
 
-	if varRef, ok := right.(*VariableReference); ok {
-		right = varRef.value
+	if ref, ok := right.(Reference); ok {
+		right = ref.Load()
+	}
+
+	if ref, ok := left.(Reference); ok {
+		left = ref.Load()
 	}
 
-	if varRef, ok := left.(*VariableReference); ok {
-		left = varRef.value
+	if result, ok := evaluate_operator_overload(expectedExpression.Operator.Kind, left, right); ok {
+		return result
 	}
 
 	switch expectedExpression.Operator.Kind {
@@ -289,8 +296,25 @@ func evaluate_call_expression(expression ast.Expression, scope *Scope) (result V
 		panic(err)
 	}
 
+	function := evaluate_callee(expectedExpression.Caller, scope)
+
+	params := make([]Value, 0)
+	for _, param := range expectedExpression.Params {
+		params = append(params, evaluate_expression(param, scope).Clone())
+	}
+
+	result, err = function.Call(params...)
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+// evaluate_callee resolves the function a call expression invokes
+func evaluate_callee(caller ast.Expression, scope *Scope) Function {
 	var function Function
-	switch caller := expectedExpression.Caller.(type) {
+	switch caller := caller.(type) {
 	case ast.SymbolExpression:
 		ref, err := scope.Resolve(caller.Value)
 		if err != nil {
@@ -332,25 +356,15 @@ func evaluate_call_expression(expression ast.Expression, scope *Scope) (result V
 			break
 		}
 
-		if ref, ok := value.(*VariableReference); ok {
-			function = ref.value.(Function)
+		if ref, ok := value.(Reference); ok {
+			function = ref.Load().(Function)
 			break
 		}
 
 		panic("cannot call non-function values")
 	}
 
-	params := make([]Value, 0)
-	for _, param := range expectedExpression.Params {
-		params = append(params, evaluate_expression(param, scope).Clone())
-	}
-
-	result, err = function.Call(params...)
-	if err != nil {
-		panic(err)
-	}
-
-	return result
+	return function
 }
 
 func evaluate_function_declaration_expression(expression ast.Expression, scope *Scope) Value {
@@ -375,32 +389,47 @@ func evaluate_try_catch_expression(expression ast.Expression, scope *Scope) (res
 		panic(err)
 	}
 
-	errorIdentifier := expectedExpression.ErrorIdentifier
+	if expectedExpression.FinallyBlock != nil {
+		defer evaluate_expression(expectedExpression.FinallyBlock, scope)
+	}
 
 	defer func() {
-		if r := recover(); r != nil {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if is_control_flow(r) {
+			panic(r)
+		}
+
+		value := exception_value(r)
+		for _, clause := range expectedExpression.CatchClauses {
+			if clause.ErrorType != nil && !EvaluateType(clause.ErrorType, scope).Equals(value.Type()) {
+				continue
+			}
+
 			catchScope := NewScope(scope)
-			if errorIdentifier != "" {
-				var value Value
-				switch e := r.(type) {
-				case error:
-					value = NewError(e.Error())
-				case string:
-					value = NewError(e)
-				default:
-					value = NewError(fmt.Sprintf("%v", e))
-				}
+			catchScope.storage[exception_identifier] = &VariableReference{
+				identifier:   exception_identifier,
+				isConstant:   true,
+				value:        value,
+				explicitType: PrimitiveType{AnyType},
+			}
+			if clause.ErrorIdentifier != "" {
 				ref := NewVariableReference(
-					errorIdentifier,
+					clause.ErrorIdentifier,
 					true,
 					value,
-					PrimitiveType{ErrorType},
+					value.Type(),
 				)
 				catchScope.Declare(ref)
 			}
 
-			result = evaluate_expression(expectedExpression.CatchBlock, catchScope)
+			result = evaluate_expression(clause.Body, catchScope)
+			return
 		}
+
+		panic(r)
 	}()
 
 	result = evaluate_expression(expectedExpression.TryBlock, scope)
@@ -473,10 +502,7 @@ func evaluate_computed_member_expression(expression ast.Expression, scope *Scope
 		panic(err)
 	}
 
-	ownerValue := evaluate_expression(expectedExpression.Owner, scope)
-	if ref, ok := ownerValue.(Reference); ok {
-		ownerValue = ref.Load()
-	}
+	ownerValue, ownerRef := evaluate_owner(expectedExpression.Owner, scope)
 
 	property := evaluate_expression(expectedExpression.Property, scope)
 	if ref, ok := property.(Reference); ok {
@@ -565,11 +591,19 @@ func evaluate_computed_member_expression(expression ast.Expression, scope *Scope
 
 		return attr.Reference
 	case StructInstantiation:
+		if method, ok := owner.Method("op_index", ownerRef); ok {
+			result, err := method.Call(property)
+			if err != nil {
+				panic(err)
+			}
+			return result
+		}
+
 		propertyName, ok := property.(String)
 		if !ok {
 			panic("Computed member access must use string expression for property")
 		}
-		attr, exists := owner.constructor._type.storage[propertyName.Value()]
+		owner, ownerRef, attr, exists := owner.Promote(propertyName.Value(), ownerRef)
 		if !exists {
 			panic(fmt.Sprintf("Unknown struct member: %s", propertyName.Value()))
 		}
@@ -582,12 +616,7 @@ func evaluate_computed_member_expression(expression ast.Expression, scope *Scope
 		if !exists {
 			if ref, ok := attr.Reference.(*FunctionReference); ok {
 				if fn, ok := ref.value.(*FunctionValue); ok {
-					// Create a new function value with the closure containing self
-					newFn := *fn
-					newClosure := NewScope(fn.closure)
-					newClosure.Declare(NewVariableReference("self", true, owner, owner.constructor.Type()))
-					newFn.closure = newClosure
-					return NewFunctionReference(ref.identifier, &newFn)
+					return bind_method(fn, owner, ownerRef)
 				}
 			}
 			panic(fmt.Sprintf("Member '%s' not initialized", propertyName.Value()))
@@ -598,12 +627,7 @@ func evaluate_computed_member_expression(expression ast.Expression, scope *Scope
 			if structInst, ok := loaded.(StructInstantiation); ok {
 				if methodRef, ok := structInst.storage[propertyName.Value()]; ok {
 					if fn, ok := methodRef.Load().(*FunctionValue); ok {
-						// Create a new function value with the closure containing self
-						newFn := *fn
-						newClosure := NewScope(fn.closure)
-						newClosure.Declare(NewVariableReference("self", true, structInst, structInst.constructor.Type()))
-						newFn.closure = newClosure
-						return NewFunctionReference(propertyName.Value(), &newFn)
+						return bind_method(fn, structInst, ref)
 					}
 				}
 			}
@@ -616,16 +640,43 @@ func evaluate_computed_member_expression(expression ast.Expression, scope *Scope
 	}
 }
 
+// evaluate_owner evaluates the owner of a member access along with the
+// reference holding it, if any. Struct instances are reached through pointers.
+func evaluate_owner(expression ast.Expression, scope *Scope) (Value, Reference) {
+	var ownerRef Reference
+	var ownerValue Value
+	if symbol, ok := expression.(ast.SymbolExpression); ok {
+		ref, err := scope.Resolve(symbol.Value)
+		if err != nil {
+			panic(fmt.Errorf("the name '%v' does not exist in the current scope", symbol.Value))
+		}
+		ownerValue = ref
+	} else {
+		ownerValue = evaluate_expression(expression, scope)
+	}
+
+	if ref, ok := ownerValue.(Reference); ok {
+		ownerRef = ref
+		ownerValue = ref.Load()
+	}
+
+	if ptr, ok := ownerValue.(*Pointer); ok && ptr.target != nil {
+		if _, ok := ptr.target.Load().(StructInstantiation); ok {
+			ownerRef = ptr.target
+			ownerValue = ptr.target.Load()
+		}
+	}
+
+	return ownerValue, ownerRef
+}
+
 func evaluate_member_expression(expression ast.Expression, scope *Scope) Value {
 	expectedExpression, err := ast.ExpectExpression[ast.MemberExpression](expression)
 	if err != nil {
 		panic(err)
 	}
 
-	ownerValue := evaluate_expression(expectedExpression.Owner, scope)
-	if ref, ok := ownerValue.(Reference); ok {
-		ownerValue = ref.Load()
-	}
+	ownerValue, ownerRef := evaluate_owner(expectedExpression.Owner, scope)
 
 	property, ok := expectedExpression.Property.(ast.SymbolExpression)
 	if !ok {
@@ -695,12 +746,50 @@ func evaluate_member_expression(expression ast.Expression, scope *Scope) Value {
 
 		panic(fmt.Sprintf("Unknown error method: %s", property.Value))
 
-	case *Module:
-		if method, exists := owner.exports[property.Value]; exists {
+	case Regex:
+		if method, exists := owner.methods[property.Value]; exists {
+			return method
+		}
+
+		panic(fmt.Sprintf("Unknown regex method: %s", property.Value))
+
+	case *XmlElement:
+		if method, exists := owner.methods[property.Value]; exists {
+			return method
+		}
+
+		panic(fmt.Sprintf("Unknown xml method: %s", property.Value))
+
+	case *Stream:
+		if method, exists := owner.methods[property.Value]; exists {
+			return method
+		}
+
+		panic(fmt.Sprintf("Unknown %s method: %s", owner.name, property.Value))
+
+	case *File:
+		if method, exists := owner.methods[property.Value]; exists {
 			return method
 		}
 
-		panic("Unknown module member")
+		panic(fmt.Sprintf("Unknown file method: %s", property.Value))
+
+	case *Process:
+		if method, exists := owner.methods[property.Value]; exists {
+			return method
+		}
+
+		panic(fmt.Sprintf("Unknown process method: %s", property.Value))
+
+	case *Client:
+		if method, exists := owner.methods[property.Value]; exists {
+			return method
+		}
+
+		panic(fmt.Sprintf("Unknown client method: %s", property.Value))
+
+	case *Module:
+		return owner.Member(property.Value)
 
 	case *Struct:
 		attr, exists := owner._type.storage[property.Value]
@@ -715,7 +804,7 @@ func evaluate_member_expression(expression ast.Expression, scope *Scope) Value {
 		return attr.Reference
 
 	case StructInstantiation:
-		attr, exists := owner.constructor._type.storage[property.Value]
+		owner, ownerRef, attr, exists := owner.Promote(property.Value, ownerRef)
 		if !exists {
 			panic(fmt.Sprintf("Unknown struct member: %s", property.Value))
 		}
@@ -728,11 +817,7 @@ func evaluate_member_expression(expression ast.Expression, scope *Scope) Value {
 		if !exists {
 			if ref, ok := attr.Reference.(*FunctionReference); ok {
 				if fn, ok := ref.value.(*FunctionValue); ok {
-					newFn := *fn
-					newClosure := NewScope(fn.closure)
-					newClosure.Declare(NewVariableReference("self", true, owner, owner.constructor.Type()))
-					newFn.closure = newClosure
-					return NewFunctionReference(ref.identifier, &newFn)
+					return bind_method(fn, owner, ownerRef)
 				}
 			}
 			panic(fmt.Sprintf("Member '%s' not initialized", property.Value))
@@ -742,11 +827,7 @@ func evaluate_member_expression(expression ast.Expression, scope *Scope) Value {
 			if structInst, ok := loaded.(StructInstantiation); ok {
 				if methodRef, ok := structInst.storage[property.Value]; ok {
 					if fn, ok := methodRef.Load().(*FunctionValue); ok {
-						newFn := *fn
-						newClosure := NewScope(fn.closure)
-						newClosure.Declare(NewVariableReference("self", true, structInst, structInst.constructor.Type()))
-						newFn.closure = newClosure
-						return NewFunctionReference(property.Value, &newFn)
+						return bind_method(fn, structInst, ref)
 					}
 				}
 			}
@@ -808,27 +889,9 @@ func evaluate_struct_instantiation_expression(expression ast.Expression, scope *
 		panic(err)
 	}
 
-	storage := make(map[string]Reference)
-
-	for identifier, property := range constructorStruct._type.storage {
-		if !property.isStatic {
-			if ref, ok := property.Reference.(*VariableReference); ok {
-				var defaultValue Value
-				if ref.value != nil {
-					defaultValue = ref.value.Clone()
-				} else {
-					defaultValue = ref.explicitType.DefaultValue()
-				}
-
-				storage[identifier] = NewVariableReference(
-					identifier,
-					ref.isConstant,
-					defaultValue,
-					ref.explicitType,
-				)
-			}
-		}
-	}
+	instance := NewStructInstance(constructorStruct)
+	storage := instance.storage
+	provided := make(map[string]bool)
 
 	for _, propertyExpression := range expectedExpression.Properties {
 		var propertyName string
@@ -865,14 +928,48 @@ func evaluate_struct_instantiation_expression(expression ast.Expression, scope *
 					propertyValue.Type(), propertyName, ref.explicitType))
 			}
 
-			storage[propertyName] = NewVariableReference(
-				propertyName,
-				ref.isConstant,
-				propertyValue,
-				ref.explicitType,
+			if structAttr.validator != nil {
+				validate_field(structAttr.validator, propertyName, propertyValue)
+			}
+
+			field := NewFieldReference(
+				NewVariableReference(
+					propertyName,
+					ref.isConstant,
+					propertyValue,
+					ref.explicitType,
+				),
+				structAttr,
 			)
+			if validated, ok := field.(*FieldReference); ok {
+				validated.assigned = true
+			}
+			storage[propertyName] = field
+			provided[propertyName] = true
 		}
 	}
 
-	return NewStructInstaniation(*constructorStruct, storage)
+	constructorStruct.check_fields(instance, func(name string) bool { return provided[name] })
+
+	return instance
+}
+
+func evaluate_constructor_call_expression(expression ast.Expression, scope *Scope) Value {
+	expectedExpression, err := ast.ExpectExpression[ast.ConstructorCallExpression](expression)
+	if err != nil {
+		panic(err)
+	}
+
+	constructor := evaluate_expression(expectedExpression.Constructor, scope)
+	constructorStruct, err := ExpectValue[*Struct](constructor)
+	if err != nil {
+		panic(err)
+	}
+
+	args := make([]Value, 0)
+	for _, arg := range expectedExpression.Arguments {
+		args = append(args, evaluate_expression(arg, scope).Clone())
+	}
+
+	return constructorStruct.construct(args)
 }
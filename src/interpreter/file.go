@@ -0,0 +1,463 @@
+package interpreter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileType represents the type of an open file handle
+type FileType struct{}
+
+// FileType implements the Type interface
+func (FileType) String() string { return "file" }
+func (f FileType) Equals(other Type) bool {
+	_, ok := other.(FileType)
+	return ok
+}
+func (f FileType) DefaultValue() Value { return NewNil() }
+
+// File is a handle on a file opened with os.open. Reads are buffered, writes
+// and seeks first give back what was buffered so the position stays where
+// the script expects it. Handles are shared by reference.
+type File struct {
+	path    string
+	mode    string
+	handle  *os.File
+	reader  *bufio.Reader
+	owner   int
+	methods map[string]Function
+}
+
+// file_modes are the modes accepted by os.open, as in C's fopen
+var file_modes = map[string]int{
+	"r":  os.O_RDONLY,
+	"r+": os.O_RDWR,
+	"w":  os.O_WRONLY | os.O_CREATE | os.O_TRUNC,
+	"w+": os.O_RDWR | os.O_CREATE | os.O_TRUNC,
+	"a":  os.O_WRONLY | os.O_CREATE | os.O_APPEND,
+	"a+": os.O_RDWR | os.O_CREATE | os.O_APPEND,
+	"x":  os.O_WRONLY | os.O_CREATE | os.O_EXCL,
+	"x+": os.O_RDWR | os.O_CREATE | os.O_EXCL,
+}
+
+// OpenFile opens the file at path in one of the file_modes
+func OpenFile(path string, mode string) *File {
+	flags, ok := file_modes[mode]
+	if !ok {
+		throw_error(ErrorCodeInvalid, "unknown file mode '%s', expected r, r+, w, w+, a, a+, x or x+", mode)
+	}
+
+	handle, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		throw_native_error("", err)
+	}
+
+	file := &File{
+		path:    path,
+		mode:    mode,
+		handle:  handle,
+		reader:  bufio.NewReader(handle),
+		owner:   file_frame,
+		methods: make(map[string]Function),
+	}
+	file.init_methods()
+	open_files = append(open_files, file)
+	return file
+}
+
+// File implements the Value interface
+func (*File) Type() Type       { return FileType{} }
+func (f *File) Clone() Value   { return f }
+func (f *File) String() string { return fmt.Sprintf("file(%s, %s)", f.path, f.mode) }
+
+// Close closes the handle, closing it again does nothing
+func (f *File) Close() error {
+	if f.handle == nil {
+		return nil
+	}
+
+	for i, file := range open_files {
+		if file == f {
+			open_files = append(open_files[:i], open_files[i+1:]...)
+			break
+		}
+	}
+
+	err := f.handle.Close()
+	f.handle = nil
+	return err
+}
+
+// open_handle returns the handle, throwing once the file is closed
+func (f *File) open_handle() *os.File {
+	if f.handle == nil {
+		throw_error(ErrorCodeIO, "file %s is closed", f.path)
+	}
+	return f.handle
+}
+
+// unread moves the position back over the input buffered but not yet read
+func (f *File) unread() {
+	handle := f.open_handle()
+	if buffered := f.reader.Buffered(); buffered > 0 {
+		if _, err := handle.Seek(-int64(buffered), io.SeekCurrent); err != nil {
+			throw_native_error(f.path, err)
+		}
+	}
+	f.reader.Reset(handle)
+}
+
+func (f *File) read_line() (string, bool) {
+	f.open_handle()
+	return read_line(f.reader, f.path)
+}
+
+func (f *File) init_methods() {
+	// read(n?: number): string | nil
+	// Purpose: Reads up to n bytes, or the rest of the file, nil at the end
+	f.methods["read"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			f.open_handle()
+
+			var data []byte
+			var err error
+			if len(args) > 0 {
+				count, ok := args[0].(Number)
+				if !ok || count.Value() < 0 {
+					throw_error(ErrorCodeInvalid, "read expects a byte count but got %v", args[0])
+				}
+				data = make([]byte, int(count.Value()))
+				var read int
+				read, err = io.ReadFull(f.reader, data)
+				data = data[:read]
+				if err == io.ErrUnexpectedEOF || (err == io.EOF && count.Value() == 0) {
+					err = nil
+				}
+			} else {
+				data, err = io.ReadAll(f.reader)
+				if err == nil && len(data) == 0 {
+					err = io.EOF
+				}
+			}
+
+			if err == io.EOF {
+				return NewNil()
+			}
+			if err != nil {
+				throw_native_error(f.path, err)
+			}
+			return NewString(string(data))
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
+
+	// read_line(): string | nil
+	// Purpose: Reads the next line without its line ending, nil at the end
+	f.methods["read_line"] = NewNativeFunction(
+		func(args ...Value) Value {
+			line, ok := f.read_line()
+			if !ok {
+				return NewNil()
+			}
+			return NewString(line)
+		},
+		[]Type{},
+		PrimitiveType{AnyType},
+	)
+
+	// lines(): []string
+	// Purpose: Reads the remaining lines
+	f.methods["lines"] = NewNativeFunction(
+		func(args ...Value) Value {
+			lines := make([]Value, 0)
+			for {
+				line, ok := f.read_line()
+				if !ok {
+					break
+				}
+				lines = append(lines, NewString(line))
+			}
+			return NewSlice(lines, PrimitiveType{StringType})
+		},
+		[]Type{},
+		NewSliceType(PrimitiveType{StringType}),
+	)
+
+	// each_line(callback: fn(line, number?)): nil
+	// Purpose: Streams the remaining lines one at a time, stopping when the callback returns false
+	f.methods["each_line"] = NewNativeFunction(
+		func(args ...Value) Value {
+			each_line(args[0], f.read_line)
+			return NewNil()
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	// write(data: string): number
+	// Purpose: Writes data at the current position, or at the end in append mode, returning the bytes written
+	f.methods["write"] = NewNativeFunction(
+		func(args ...Value) Value {
+			f.unread()
+			written, err := io.WriteString(f.handle, args[0].(String).Value())
+			if err != nil {
+				throw_native_error(f.path, err)
+			}
+			return NewNumber(float64(written))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{NumberType},
+	)
+
+	// seek(offset: number, whence?: string): number
+	// Purpose: Moves to offset from "start", "current" or "end", returning the new position
+	f.methods["seek"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			whence := io.SeekStart
+			if len(args) > 1 {
+				name, _ := args[1].(String)
+				switch name.Value() {
+				case "start":
+				case "current":
+					whence = io.SeekCurrent
+				case "end":
+					whence = io.SeekEnd
+				default:
+					throw_error(ErrorCodeInvalid, "seek expects start, current or end but got %v", args[1])
+				}
+			}
+
+			f.unread()
+			position, err := f.handle.Seek(int64(args[0].(Number).Value()), whence)
+			if err != nil {
+				throw_native_error(f.path, err)
+			}
+			return NewNumber(float64(position))
+		},
+		[]Type{PrimitiveType{NumberType}, PrimitiveType{AnyType}},
+		PrimitiveType{NumberType},
+	)
+
+	// position(): number
+	// Purpose: Returns the current position in bytes from the start
+	f.methods["position"] = NewNativeFunction(
+		func(args ...Value) Value {
+			f.unread()
+			position, err := f.handle.Seek(0, io.SeekCurrent)
+			if err != nil {
+				throw_native_error(f.path, err)
+			}
+			return NewNumber(float64(position))
+		},
+		[]Type{},
+		PrimitiveType{NumberType},
+	)
+
+	// stat(): map[string -> any]
+	// Purpose: Describes the file, see os.stat
+	f.methods["stat"] = NewNativeFunction(
+		func(args ...Value) Value {
+			info, err := f.open_handle().Stat()
+			if err != nil {
+				throw_native_error(f.path, err)
+			}
+			return file_info_map(info)
+		},
+		[]Type{},
+		NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
+	)
+
+	// path(): string
+	// Purpose: Returns the path the file was opened with
+	f.methods["path"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(f.path)
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	// close(): nil
+	// Purpose: Closes the file, closing it again does nothing
+	f.methods["close"] = NewNativeFunction(
+		func(args ...Value) Value {
+			if err := f.Close(); err != nil {
+				throw_native_error(f.path, err)
+			}
+			return NewNil()
+		},
+		[]Type{},
+		PrimitiveType{NilType},
+	)
+}
+
+// open_files are the handles not closed yet, in the order they were opened
+var open_files = make([]*File, 0)
+
+// file_frame numbers the function call running now, 0 at the top level of
+// the program. A file is owned by the call that opened it.
+var (
+	file_frame    = 0
+	file_sequence = 0
+)
+
+// call_frame is a function call as seen by the files it opens
+type call_frame struct {
+	id     int
+	parent int
+}
+
+// enter_file_frame starts a function call, the files opened until it ends
+// are owned by it
+func enter_file_frame() call_frame {
+	file_sequence++
+	frame := call_frame{id: file_sequence, parent: file_frame}
+	file_frame = frame.id
+	return frame
+}
+
+// leave_file_frame ends a function call started by enter_file_frame
+func leave_file_frame(frame call_frame) {
+	file_frame = frame.parent
+}
+
+// close_frame_files closes the handles a call opened itself when an error
+// unwinds it. Handles opened by the functions it called are theirs, the
+// program may still hold them.
+func close_frame_files(frame call_frame) {
+	for i := len(open_files) - 1; i >= 0; i-- {
+		if i < len(open_files) && open_files[i].owner == frame.id {
+			open_files[i].Close()
+		}
+	}
+}
+
+// close_files closes every handle left open when the program ends
+func close_files() {
+	for len(open_files) > 0 {
+		open_files[len(open_files)-1].Close()
+	}
+}
+
+// file_info_map describes a file as a map with its name, size in bytes,
+// permissions, modification time in Unix seconds and whether it is a
+// directory
+func file_info_map(info fs.FileInfo) Value {
+	entry := func(key string, value Value) MapEntry {
+		return MapEntry{key: NewString(key), value: value}
+	}
+
+	return NewMap([]MapEntry{
+		entry("name", NewString(info.Name())),
+		entry("size", NewNumber(float64(info.Size()))),
+		entry("mode", NewString(info.Mode().String())),
+		entry("modified", NewNumber(float64(info.ModTime().Unix()))),
+		entry("is_dir", NewBoolean(info.IsDir())),
+	}, PrimitiveType{StringType}, PrimitiveType{AnyType})
+}
+
+// copy_file copies the contents and permissions of a file
+func copy_file(source string, destination string) error {
+	input, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	info, err := input.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &fs.PathError{Op: "copy", Path: source, Err: fmt.Errorf("is a directory")}
+	}
+
+	output, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(output, input); err != nil {
+		output.Close()
+		return err
+	}
+	return output.Close()
+}
+
+// glob returns the paths matching a pattern in sorted order. Besides the
+// wildcards of filepath.Match, a ** segment matches any number of
+// directories.
+func glob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+
+	pattern = filepath.Clean(pattern)
+	segments := strings.Split(pattern, string(filepath.Separator))
+
+	// Walk from the directory before the first wildcard
+	root := make([]string, 0)
+	for _, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			break
+		}
+		root = append(root, segment)
+	}
+	base := strings.Join(root, string(filepath.Separator))
+	if base == "" && filepath.IsAbs(pattern) {
+		base = string(filepath.Separator)
+	}
+	if base == "" {
+		base = "."
+	}
+
+	matches := make([]string, 0)
+	err := filepath.WalkDir(base, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == base && os.IsNotExist(err) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+
+		ok, err := glob_match(segments, strings.Split(path, string(filepath.Separator)))
+		if err != nil {
+			return err
+		}
+		if ok {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// glob_match matches path segments against pattern segments, ** matching
+// zero or more of them
+func glob_match(pattern []string, path []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for skip := 0; skip <= len(path); skip++ {
+				if ok, err := glob_match(pattern[1:], path[skip:]); ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(path) == 0 {
+			return false, nil
+		}
+
+		ok, err := filepath.Match(pattern[0], path[0])
+		if !ok || err != nil {
+			return false, err
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0, nil
+}
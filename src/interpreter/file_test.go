@@ -0,0 +1,100 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func file_source(t *testing.T, source string) string {
+	path := filepath.ToSlash(filepath.Join(t.TempDir(), "log.txt"))
+	return strings.ReplaceAll(source, "LOG", path)
+}
+
+func TestFileOpenedByFailingCallIsClosed(t *testing.T) {
+	expect_output(t, file_source(t, `
+import os from "os"
+import fmt from "fmt"
+let files = map{}
+fn fail() {
+  files["log"] = os.open("LOG", "w")
+  throw "boom"
+}
+try {
+  fail()
+} catch (e) {
+  fmt.println("caught", e)
+}
+try {
+  files["log"].write("x")
+} catch (e) {
+  fmt.println(e.code())
+}
+`), "caught boom\nio")
+}
+
+func TestFileReturnedByCalleeSurvivesCaughtError(t *testing.T) {
+	expect_output(t, file_source(t, `
+import os from "os"
+import fmt from "fmt"
+let files = map{}
+fn open_log() {
+  files["log"] = os.open("LOG", "w")
+}
+fn setup() {
+  open_log()
+  throw "setup failed"
+}
+try {
+  setup()
+} catch (e) {
+  fmt.println("caught", e)
+}
+fmt.println(files["log"].write("still open"))
+files["log"].close()
+fmt.println(os.read_file("LOG"))
+`), "caught setup failed\n10\nstill open")
+}
+
+func TestFilesAreClosedWhenTheProgramEnds(t *testing.T) {
+	_, err := run_source(t, file_source(t, `
+import os from "os"
+let log = os.open("LOG", "w")
+throw "unhandled"
+`))
+	if err == nil {
+		t.Fatal("expected the uncaught error")
+	}
+	if len(open_files) != 0 {
+		t.Errorf("%d files were left open", len(open_files))
+	}
+}
+
+func TestFileWriteKeepsTheTextOfStrings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.txt")
+	expect_output(t, strings.ReplaceAll(`
+import os from "os"
+const log = os.open("LOG", "w")
+log.write("l4\nl5\t\"quoted\"\n")
+log.write("C:\\new\\table " + "\d")
+log.close()
+`, "LOG", filepath.ToSlash(path)), "")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "l4\nl5\t\"quoted\"\nC:\\new\\table \\d"; string(data) != expected {
+		t.Errorf("unexpected file content %q, expected %q", data, expected)
+	}
+}
+
+func TestPrintDoesNotReadEscapesOfValues(t *testing.T) {
+	expect_output(t, `
+import fmt from "fmt"
+const path = "C:\\new\\table"
+fmt.println(path, path.len())
+fmt.printf("%s|\n", path)
+`, "C:\\new\\table 12\nC:\\new\\table|")
+}
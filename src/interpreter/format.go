@@ -0,0 +1,187 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// format_string implements the printf family of functions. Verbs are parsed
+// here and each argument is converted to the matching Go value so that flags,
+// width and precision behave exactly like Go's fmt package.
+func format_string(format string, args []Value) string {
+	var result strings.Builder
+	argIndex := 0
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			result.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		for i < len(format) && is_digit(format[i]) {
+			i++
+		}
+		if i < len(format) && format[i] == '.' {
+			i++
+			for i < len(format) && is_digit(format[i]) {
+				i++
+			}
+		}
+
+		if i >= len(format) {
+			result.WriteString("%!(NOVERB)")
+			break
+		}
+
+		spec := format[start:i]
+		verb := format[i]
+		if verb == '%' {
+			result.WriteByte('%')
+			continue
+		}
+
+		if argIndex >= len(args) {
+			result.WriteString(fmt.Sprintf("%%!%c(MISSING)", verb))
+			continue
+		}
+
+		result.WriteString(format_verb(spec, verb, args[argIndex]))
+		argIndex++
+	}
+
+	if argIndex < len(args) {
+		extra := make([]string, 0, len(args)-argIndex)
+		for _, arg := range args[argIndex:] {
+			extra = append(extra, fmt.Sprintf("%s=%s", arg.Type(), format_value(arg, false)))
+		}
+		result.WriteString("%!(EXTRA " + strings.Join(extra, ", ") + ")")
+	}
+
+	return result.String()
+}
+
+func format_verb(spec string, verb byte, value Value) string {
+	if ref, ok := value.(Reference); ok {
+		value = ref.Load()
+	}
+
+	bad := func() string {
+		return fmt.Sprintf("%%!%c(%s=%s)", verb, value.Type(), format_value(value, false))
+	}
+
+	switch verb {
+	case 'v':
+		quote := strings.Contains(spec, "#")
+		return fmt.Sprintf(strings.ReplaceAll(spec, "#", "")+"s", format_value(value, quote))
+	case 's':
+		return fmt.Sprintf(spec+"s", format_value(value, false))
+	case 'q':
+		return fmt.Sprintf(spec+"q", format_value(value, false))
+	case 'd', 'b', 'o', 'c', 'U':
+		number, ok := value.(Number)
+		if !ok {
+			return bad()
+		}
+		return fmt.Sprintf(spec+string(verb), int64(number.Value()))
+	case 'x', 'X':
+		switch value := value.(type) {
+		case Number:
+			if value.IsInteger() {
+				return fmt.Sprintf(spec+string(verb), int64(value.Value()))
+			}
+			return fmt.Sprintf(spec+string(verb), value.Value())
+		case String:
+			return fmt.Sprintf(spec+string(verb), value.Value())
+		default:
+			return bad()
+		}
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		number, ok := value.(Number)
+		if !ok {
+			return bad()
+		}
+		return fmt.Sprintf(spec+string(verb), number.Value())
+	case 't':
+		boolean, ok := value.(Boolean)
+		if !ok {
+			return bad()
+		}
+		return fmt.Sprintf(spec+"t", boolean.Value())
+	default:
+		return bad()
+	}
+}
+
+// format_value renders a value on a single line the way %v prints it.
+// Nested strings are quoted when quote is set (%#v).
+func format_value(value Value, quote bool) string {
+	switch value := value.(type) {
+	case Reference:
+		return format_value(value.Load(), quote)
+	case String:
+		if quote {
+			return strconv.Quote(value.Value())
+		}
+		return value.Value()
+	case Number:
+		if value.IsInteger() && math.Abs(value.Value()) < 1e15 {
+			return strconv.FormatInt(int64(value.Value()), 10)
+		}
+		return strconv.FormatFloat(value.Value(), 'g', -1, 64)
+	case Array:
+		return format_elements(value.elements, quote)
+	case Slice:
+		return format_elements(*value.elements, quote)
+	case Map:
+		items := make([]string, 0, len(*value.entries))
+		for _, entry := range *value.entries {
+			items = append(items, format_value(entry.key, quote)+" -> "+format_value(entry.value, quote))
+		}
+		return "map{" + strings.Join(items, ", ") + "}"
+	case StructInstantiation:
+		if !quote {
+			if str, ok := value.to_string(); ok {
+				return str
+			}
+		}
+
+		names := make([]string, 0, len(value.storage))
+		for name := range value.storage {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		items := make([]string, 0, len(names))
+		for _, name := range names {
+			items = append(items, name+": "+format_value(value.storage[name], quote))
+		}
+		return value.constructor.identifier + "{" + strings.Join(items, ", ") + "}"
+	case *Pointer:
+		if value.target == nil {
+			return "nil"
+		}
+		return "&" + format_value(value.target.Load(), quote)
+	default:
+		return value.String()
+	}
+}
+
+func format_elements(elements []Value, quote bool) string {
+	items := make([]string, len(elements))
+	for i, element := range elements {
+		items[i] = format_value(element, quote)
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func is_digit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
@@ -80,6 +80,7 @@ type FunctionValue struct {
 	body       []ast.Statement
 	returnType Type
 	closure    *Scope
+	receiver   *Receiver
 }
 
 func NewFunctionValue(params []ast.Parameter, body []ast.Statement, returnType Type, closure *Scope) *FunctionValue {
@@ -118,6 +119,7 @@ func (f FunctionValue) Clone() Value {
 		body:       bodyCopy,
 		returnType: f.returnType,
 		closure:    f.closure,
+		receiver:   f.receiver,
 	}
 }
 func (f FunctionValue) String() string {
@@ -134,7 +136,15 @@ func (f FunctionValue) String() string {
 	return str
 }
 func (f FunctionValue) Call(args ...Value) (result Value, err error) {
+	return f.call(nil, args...)
+}
+
+// call invokes the function, declaring the receiver first if it is a method
+func (f FunctionValue) call(receiver Value, args ...Value) (result Value, err error) {
+	// Files opened by the call itself are closed when an error unwinds it
+	frame := enter_file_frame()
 	defer func() {
+		leave_file_frame(frame)
 		if r := recover(); r != nil {
 			switch e := r.(type) {
 			case ReturnError:
@@ -144,14 +154,26 @@ func (f FunctionValue) Call(args ...Value) (result Value, err error) {
 				}
 				err = nil
 			case error:
+				close_frame_files(frame)
 				err = e
 			default:
+				close_frame_files(frame)
 				panic(e)
 			}
 		}
 	}()
 
-	functionScope := NewScope(f.closure)
+	functionScope := NewFunctionScope(f.closure)
+	defer functionScope.RunDeferred()
+
+	if receiver != nil {
+		identifier := "self"
+		if f.receiver != nil {
+			identifier = f.receiver.identifier
+		}
+		functionScope.Declare(NewVariableReference(identifier, true, receiver, receiver.Type()))
+	}
+
 	if len(args) > len(f.parameters) {
 		return nil, fmt.Errorf("expected at most %d arguments but got %d",
 			len(f.parameters), len(args))
@@ -188,6 +210,24 @@ func (f FunctionValue) Call(args ...Value) (result Value, err error) {
 	return NewNil(), nil
 }
 
+// function_arity returns the number of parameters a function declares
+func function_arity(function Function) int {
+	switch fn := function.(type) {
+	case FunctionValue:
+		return len(fn.parameters)
+	case *FunctionValue:
+		return len(fn.parameters)
+	case NativeFunctionValue:
+		return len(fn.paramTypes)
+	case *NativeFunctionValue:
+		return len(fn.paramTypes)
+	case BoundMethod:
+		return len(fn.method.parameters)
+	default:
+		return 0
+	}
+}
+
 type FunctionReference struct {
 	identifier string
 	value      Function
@@ -275,6 +315,7 @@ type NativeFunctionValue struct {
 	value      NativeFunction
 	paramTypes []Type
 	returnType Type
+	isVariadic bool
 }
 
 func NewNativeFunction(fn NativeFunction, paramTypes []Type, returnType Type) *NativeFunctionValue {
@@ -285,6 +326,13 @@ func NewNativeFunction(fn NativeFunction, paramTypes []Type, returnType Type) *N
 	}
 }
 
+// NewVariadicNativeFunction creates a native function whose last parameter accepts any number of arguments
+func NewVariadicNativeFunction(fn NativeFunction, paramTypes []Type, returnType Type) *NativeFunctionValue {
+	function := NewNativeFunction(fn, paramTypes, returnType)
+	function.isVariadic = true
+	return function
+}
+
 // NativeFunction implements the Value interface
 func (n NativeFunctionValue) Type() Type {
 	return NativeFunctionType{
@@ -293,7 +341,9 @@ func (n NativeFunctionValue) Type() Type {
 	}
 }
 func (n NativeFunctionValue) Clone() Value {
-	return NewNativeFunction(n.value, n.paramTypes, n.returnType)
+	function := NewNativeFunction(n.value, n.paramTypes, n.returnType)
+	function.isVariadic = n.isVariadic
+	return function
 }
 func (n NativeFunctionValue) String() string {
 	str := "native_fn("
@@ -304,18 +354,26 @@ func (n NativeFunctionValue) String() string {
 		}
 		str += param.String()
 	}
+	if n.isVariadic {
+		str += "..."
+	}
 
 	str += ") -> " + n.returnType.String()
 	return str
 }
 
 func (n NativeFunctionValue) Call(args ...Value) (Value, error) {
-	if len(args) != len(n.paramTypes) {
+	if n.isVariadic {
+		if len(args) < len(n.paramTypes)-1 {
+			return NewNil(), fmt.Errorf("expected at least %d arguments but got %d", len(n.paramTypes)-1, len(args))
+		}
+	} else if len(args) != len(n.paramTypes) {
 		return NewNil(), fmt.Errorf("expected %d arguments but got %d", len(n.paramTypes), len(args))
 	}
 	for i, arg := range args {
-		if !n.paramTypes[i].Equals(arg.Type()) {
-			return NewNil(), fmt.Errorf("argument %d: expected %v but got %v", i, n.paramTypes[i], arg.Type())
+		paramType := n.paramTypes[min(i, len(n.paramTypes)-1)]
+		if !paramType.Equals(arg.Type()) {
+			return NewNil(), fmt.Errorf("argument %d: expected %v but got %v", i, paramType, arg.Type())
 		}
 	}
 
@@ -0,0 +1,57 @@
+package interpreter
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// run_source runs a program and returns what it printed to stdout and the
+// error that stopped it, if any
+func run_source(t *testing.T, source string) (string, error) {
+	t.Helper()
+
+	program, err := Parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	stdout_stream.writer = writer
+	defer func() {
+		os.Stdout = stdout
+		stdout_stream.writer = stdout
+	}()
+
+	path := filepath.Join(t.TempDir(), "main.harmony")
+	err = Run(program, path)
+	writer.Close()
+	return <-output, err
+}
+
+// expect_output runs a program that must succeed and compares its output,
+// ignoring surrounding whitespace
+func expect_output(t *testing.T, source string, expected string) {
+	t.Helper()
+
+	output, err := run_source(t, source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\noutput:\n%s", err, output)
+	}
+	if strings.TrimSpace(output) != strings.TrimSpace(expected) {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", output, expected)
+	}
+}
@@ -0,0 +1,541 @@
+package interpreter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClientType represents the type of an HTTP client made with http.client
+type ClientType struct{}
+
+// ClientType implements the Type interface
+func (ClientType) String() string { return "client" }
+func (c ClientType) Equals(other Type) bool {
+	_, ok := other.(ClientType)
+	return ok
+}
+func (c ClientType) DefaultValue() Value { return NewNil() }
+
+// Client sends HTTP requests with shared options and keeps the cookies set
+// by the servers it talks to. Clients are shared by reference.
+type Client struct {
+	options http_options
+	jar     http.CookieJar
+	methods map[string]Function
+}
+
+// http_options are the settings of a request: those of its client, overridden
+// by the options of the call
+type http_options struct {
+	timeout         time.Duration
+	retries         int
+	backoff         time.Duration
+	followRedirects bool
+	maxRedirects    int
+	baseURL         string
+	headers         http.Header
+	cookies         []*http.Cookie
+	query           url.Values
+	body            []byte
+	contentType     string
+	stream          bool
+	idempotent      *bool
+}
+
+func default_http_options() http_options {
+	return http_options{
+		timeout:         30 * time.Second,
+		backoff:         200 * time.Millisecond,
+		followRedirects: true,
+		maxRedirects:    10,
+		headers:         make(http.Header),
+		query:           make(url.Values),
+	}
+}
+
+// http_client_options are the options http.client accepts, the rest only
+// make sense for a single request
+var http_client_options = map[string]bool{
+	"timeout": true, "retries": true, "backoff": true, "follow_redirects": true,
+	"max_redirects": true, "base_url": true, "headers": true, "cookies": true, "query": true,
+}
+
+// parse_http_options applies an options map on top of the base options. The
+// client flag restricts them to the options of a client.
+func parse_http_options(base http_options, options Value, client bool) http_options {
+	opts := base
+	opts.headers = base.headers.Clone()
+	opts.cookies = append([]*http.Cookie{}, base.cookies...)
+	opts.query = make(url.Values)
+	for key, values := range base.query {
+		opts.query[key] = append([]string{}, values...)
+	}
+	if options == nil {
+		return opts
+	}
+
+	values, ok := options.(Map)
+	if !ok {
+		throw_error(ErrorCodeInvalid, "http options must be a map but got %v", options.Type())
+	}
+
+	bodies := 0
+	for _, entry := range *values.entries {
+		key := entry.key.String()
+		if client && !http_client_options[key] {
+			throw_error(ErrorCodeInvalid, "unknown http client option: %s", key)
+		}
+
+		switch key {
+		case "timeout":
+			opts.timeout = time.Duration(http_number_option(key, entry.value) * float64(time.Millisecond))
+		case "retries":
+			opts.retries = int(http_number_option(key, entry.value))
+		case "backoff":
+			opts.backoff = time.Duration(http_number_option(key, entry.value) * float64(time.Millisecond))
+		case "follow_redirects":
+			follow, ok := entry.value.(Boolean)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "http option 'follow_redirects' must be a bool but got %v", entry.value.Type())
+			}
+			opts.followRedirects = follow.Value()
+		case "max_redirects":
+			opts.maxRedirects = int(http_number_option(key, entry.value))
+		case "base_url":
+			baseURL, ok := entry.value.(String)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "http option 'base_url' must be a string but got %v", entry.value.Type())
+			}
+			opts.baseURL = baseURL.Value()
+		case "headers":
+			for _, pair := range http_pairs(key, entry.value) {
+				opts.headers.Add(pair[0], pair[1])
+			}
+		case "query":
+			for _, pair := range http_pairs(key, entry.value) {
+				opts.query.Add(pair[0], pair[1])
+			}
+		case "cookies":
+			for _, pair := range http_pairs(key, entry.value) {
+				opts.cookies = append(opts.cookies, &http.Cookie{Name: pair[0], Value: pair[1]})
+			}
+		case "body":
+			body, ok := entry.value.(String)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "http option 'body' must be a string but got %v", entry.value.Type())
+			}
+			opts.body = []byte(body.Value())
+			bodies++
+		case "json":
+			opts.body = []byte(json_stringify(entry.value, json_stringify_options(nil)))
+			opts.contentType = "application/json"
+			bodies++
+		case "form":
+			form := make(url.Values)
+			for _, pair := range http_pairs(key, entry.value) {
+				form.Add(pair[0], pair[1])
+			}
+			opts.body = []byte(form.Encode())
+			opts.contentType = "application/x-www-form-urlencoded"
+			bodies++
+		case "multipart":
+			opts.body, opts.contentType = http_multipart(entry.value)
+			bodies++
+		case "idempotent":
+			idempotent, ok := entry.value.(Boolean)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "http option 'idempotent' must be a bool but got %v", entry.value.Type())
+			}
+			value := idempotent.Value()
+			opts.idempotent = &value
+		case "stream":
+			stream, ok := entry.value.(Boolean)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "http option 'stream' must be a bool but got %v", entry.value.Type())
+			}
+			opts.stream = stream.Value()
+		default:
+			throw_error(ErrorCodeInvalid, "unknown http option: %s", key)
+		}
+	}
+
+	if bodies > 1 {
+		throw_error(ErrorCodeInvalid, "http options 'body', 'json', 'form' and 'multipart' cannot be combined")
+	}
+	return opts
+}
+
+func http_number_option(key string, value Value) float64 {
+	number, ok := value.(Number)
+	if !ok || number.Value() < 0 {
+		throw_error(ErrorCodeInvalid, "http option '%s' must be a number that is not negative but got %v", key, value)
+	}
+	return number.Value()
+}
+
+// http_pairs flattens a map of names to values into name and value pairs, a
+// slice value gives a pair for each of its elements
+func http_pairs(key string, value Value) [][2]string {
+	values, ok := value.(Map)
+	if !ok {
+		throw_error(ErrorCodeInvalid, "http option '%s' must be a map but got %v", key, value.Type())
+	}
+
+	pairs := make([][2]string, 0)
+	for _, entry := range *values.entries {
+		name := entry.key.String()
+		if list, ok := entry.value.(Slice); ok {
+			for _, element := range *list.elements {
+				pairs = append(pairs, [2]string{name, http_text(element)})
+			}
+			continue
+		}
+		pairs = append(pairs, [2]string{name, http_text(entry.value)})
+	}
+	return pairs
+}
+
+// http_text writes a value the way it appears in a header, query or form
+func http_text(value Value) string {
+	if number, ok := value.(Number); ok {
+		return strconv.FormatFloat(number.Value(), 'f', -1, 64)
+	}
+	return value.String()
+}
+
+// http_multipart encodes a multipart/form-data body. Map values are files
+// read from "path" or given as "content", with an optional "filename" and
+// "content_type", other values are plain fields.
+func http_multipart(value Value) ([]byte, string) {
+	fields, ok := value.(Map)
+	if !ok {
+		throw_error(ErrorCodeInvalid, "http option 'multipart' must be a map but got %v", value.Type())
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, entry := range *fields.entries {
+		name := entry.key.String()
+		file, ok := entry.value.(Map)
+		if !ok {
+			if err := writer.WriteField(name, http_text(entry.value)); err != nil {
+				throw_native_error("http", err)
+			}
+			continue
+		}
+
+		var content []byte
+		filename, contentType := name, "application/octet-stream"
+		for _, part := range *file.entries {
+			switch part.key.String() {
+			case "path":
+				data, err := os.ReadFile(part.value.String())
+				if err != nil {
+					throw_native_error("http", err)
+				}
+				content = data
+				filename = filepath.Base(part.value.String())
+			case "content":
+				content = []byte(part.value.String())
+			}
+		}
+		for _, part := range *file.entries {
+			switch part.key.String() {
+			case "filename":
+				filename = part.value.String()
+			case "content_type":
+				contentType = part.value.String()
+			case "path", "content":
+			default:
+				throw_error(ErrorCodeInvalid, "unknown multipart file option: %s", part.key.String())
+			}
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": name, "filename": filename}))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err == nil {
+			_, err = part.Write(content)
+		}
+		if err != nil {
+			throw_native_error("http", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		throw_native_error("http", err)
+	}
+	return body.Bytes(), writer.FormDataContentType()
+}
+
+// http_idempotent_methods are the methods that are safe to send again, a
+// retried POST or PATCH could apply twice
+var http_idempotent_methods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// http_retry_statuses are the responses worth trying again, the server was
+// busy or failed in between
+var http_retry_statuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// send_http_request sends a request, retrying transport errors and busy
+// responses of idempotent requests with a doubling delay, and describes the
+// response as a map
+func send_http_request(method string, rawURL string, opts http_options, jar http.CookieJar) Value {
+	target, err := url.Parse(rawURL)
+	if err == nil && opts.baseURL != "" {
+		var base *url.URL
+		if base, err = url.Parse(opts.baseURL); err == nil {
+			target = base.ResolveReference(target)
+		}
+	}
+	if err != nil {
+		throw_native_error("http", err)
+	}
+	if len(opts.query) > 0 {
+		query := target.Query()
+		for key, values := range opts.query {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		target.RawQuery = query.Encode()
+	}
+
+	client := &http.Client{
+		Timeout: opts.timeout,
+		Jar:     jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !opts.followRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > opts.maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", opts.maxRedirects)
+			}
+			return nil
+		},
+	}
+
+	retries := opts.retries
+	idempotent := http_idempotent_methods[method]
+	if opts.idempotent != nil {
+		idempotent = *opts.idempotent
+	}
+	if !idempotent {
+		retries = 0
+	}
+
+	var res *http.Response
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if opts.body != nil {
+			body = bytes.NewReader(opts.body)
+		}
+		req, err := http.NewRequest(method, target.String(), body)
+		if err != nil {
+			throw_native_error("http", err)
+		}
+		req.Header = opts.headers.Clone()
+		if opts.contentType != "" && req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", opts.contentType)
+		}
+		for _, cookie := range opts.cookies {
+			req.AddCookie(cookie)
+		}
+
+		res, err = client.Do(req)
+		if attempt < retries && (err != nil || http_retry_statuses[res.StatusCode]) {
+			if res != nil {
+				io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+			}
+			time.Sleep(opts.backoff << attempt)
+			continue
+		}
+		if err != nil {
+			throw_native_error("http", err)
+		}
+		break
+	}
+
+	return http_response(res, opts.stream)
+}
+
+// http_response describes a response as a map. Headers with several values
+// are joined by commas, header_values keeps them apart. A streamed body is a
+// stream to read and close, otherwise the body is read whole and a JSON body
+// is also parsed.
+func http_response(res *http.Response, stream bool) Value {
+	entry := func(key string, value Value) MapEntry {
+		return MapEntry{key: NewString(key), value: value}
+	}
+
+	names := make([]string, 0, len(res.Header))
+	for name := range res.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := make([]MapEntry, 0, len(names))
+	headerValues := make([]MapEntry, 0, len(names))
+	for _, name := range names {
+		values := make([]Value, len(res.Header[name]))
+		for i, value := range res.Header[name] {
+			values[i] = NewString(value)
+		}
+		headers = append(headers, entry(name, NewString(strings.Join(res.Header[name], ", "))))
+		headerValues = append(headerValues, entry(name, NewSlice(values, PrimitiveType{StringType})))
+	}
+
+	cookies := make([]MapEntry, 0)
+	for _, cookie := range res.Cookies() {
+		cookies = append(cookies, entry(cookie.Name, NewString(cookie.Value)))
+	}
+
+	entries := []MapEntry{
+		entry("status", NewNumber(float64(res.StatusCode))),
+		entry("statusCode", NewNumber(float64(res.StatusCode))),
+		entry("ok", NewBoolean(res.StatusCode >= 200 && res.StatusCode < 300)),
+		entry("url", NewString(res.Request.URL.String())),
+		entry("headers", NewMap(headers, PrimitiveType{StringType}, PrimitiveType{StringType})),
+		entry("header_values", NewMap(headerValues, PrimitiveType{StringType}, NewSliceType(PrimitiveType{StringType}))),
+		entry("cookies", NewMap(cookies, PrimitiveType{StringType}, PrimitiveType{StringType})),
+	}
+
+	if stream {
+		entries = append(entries, entry("body", NewReadCloseStream("response body", res.Body)))
+	} else {
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			throw_native_error("http", err)
+		}
+		entries = append(entries, entry("body", NewString(string(body))))
+
+		mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+		if len(body) > 0 && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+			entries = append(entries, entry("json", json_parse(string(body), false)))
+		}
+	}
+
+	// Each header is also a key of its own, as in the first versions
+	for _, header := range headers {
+		entries = append(entries, header)
+	}
+
+	return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{AnyType})
+}
+
+// http_methods are the request methods with a function of their own
+var http_methods = []string{"get", "head", "post", "put", "patch", "delete"}
+
+// http_request_functions returns the get, post... and request functions that
+// send requests with the given options and cookie jar
+func http_request_functions(options func() http_options, jar http.CookieJar) map[string]Function {
+	functions := make(map[string]Function)
+	for _, method := range http_methods {
+		// get(url: string, options?: map[string -> any]): map[string -> any] and so on
+		// Purpose: Sends a request with the method of the function's name
+		functions[method] = NewVariadicNativeFunction(
+			func(args ...Value) Value {
+				return http_call(strings.ToUpper(method), args, options(), jar)
+			},
+			[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+			NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
+		)
+	}
+
+	// request(method: string, url: string, options?: map[string -> any]): map[string -> any]
+	// Purpose: Sends a request with any method
+	functions["request"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			if len(args) < 2 {
+				throw_error(ErrorCodeInvalid, "request expects a method and a url")
+			}
+			method, ok := args[0].(String)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "request expects the method as a string but got %v", args[0].Type())
+			}
+			return http_call(strings.ToUpper(method.Value()), args[1:], options(), jar)
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
+	)
+	return functions
+}
+
+// http_call sends a request for the url and optional options of a call
+func http_call(method string, args []Value, base http_options, jar http.CookieJar) Value {
+	if len(args) > 2 {
+		throw_error(ErrorCodeInvalid, "expected a url and options but got %d arguments", len(args))
+	}
+	target, ok := args[0].(String)
+	if !ok {
+		throw_error(ErrorCodeInvalid, "expected the url as a string but got %v", args[0].Type())
+	}
+
+	var options Value
+	if len(args) > 1 {
+		options = args[1]
+	}
+	return send_http_request(method, target.Value(), parse_http_options(base, options, false), jar)
+}
+
+// NewClient creates a client with the given options and an empty cookie jar
+func NewClient(options Value) *Client {
+	jar, _ := cookiejar.New(nil)
+	client := &Client{
+		options: parse_http_options(default_http_options(), options, true),
+		jar:     jar,
+	}
+	client.methods = http_request_functions(func() http_options { return client.options }, jar)
+
+	// cookies(url: string): map[string -> string]
+	// Purpose: Returns the cookies the client sends to a url
+	client.methods["cookies"] = NewNativeFunction(
+		func(args ...Value) Value {
+			target, err := url.Parse(args[0].(String).Value())
+			if err != nil {
+				throw_native_error("http", err)
+			}
+
+			entries := make([]MapEntry, 0)
+			for _, cookie := range jar.Cookies(target) {
+				entries = append(entries, MapEntry{key: NewString(cookie.Name), value: NewString(cookie.Value)})
+			}
+			return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{StringType})
+		},
+		[]Type{PrimitiveType{StringType}},
+		NewMapType(PrimitiveType{StringType}, PrimitiveType{StringType}),
+	)
+	return client
+}
+
+// Client implements the Value interface
+func (*Client) Type() Type       { return ClientType{} }
+func (c *Client) Clone() Value   { return c }
+func (c *Client) String() string { return fmt.Sprintf("client(%s)", c.options.baseURL) }
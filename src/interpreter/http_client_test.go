@@ -0,0 +1,182 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// expect_http_output runs a program against a test server, URL in the
+// source is replaced by the address of the server
+func expect_http_output(t *testing.T, handler http.Handler, source string, expected string) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	expect_output(t, strings.ReplaceAll(source, "URL", server.URL), expected)
+}
+
+func TestHttpQueryEncoding(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.RawQuery)
+	})
+	expect_http_output(t, handler, `
+import http from "http"
+const response = http.get("URL/search?page=1", map{
+    "query" -> map{"q" -> "a b&c=d", "tag" -> []string{"x", "y",},},
+})
+println(response["body"])
+`, "page=1&q=a+b%26c%3Dd&tag=x&tag=y")
+}
+
+func TestHttpRetriesIdempotentRequests(t *testing.T) {
+	var attempts atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/reset" {
+			attempts.Store(0)
+			return
+		}
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		fmt.Fprint(w, attempts.Load())
+	})
+	expect_http_output(t, handler, `
+import http from "http"
+const options = map{"retries" -> 2, "backoff" -> 1,}
+let response = http.get("URL", options)
+println(response["status"], response["body"])
+
+http.get("URL/reset")
+response = http.post("URL", options)
+println(response["status"], response["body"])
+
+http.get("URL/reset")
+response = http.post("URL", map{"retries" -> 2, "backoff" -> 1, "idempotent" -> true,})
+println(response["status"], response["body"])
+`, "200 3\n503 1\n200 3")
+}
+
+func TestHttpRedirectLimit(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/moved":
+			http.Redirect(w, r, "/target", http.StatusFound)
+		default:
+			fmt.Fprint(w, r.URL.Path)
+		}
+	})
+	expect_http_output(t, handler, `
+import http from "http"
+println(http.get("URL/moved")["body"])
+println(http.get("URL/moved", map{"follow_redirects" -> false,})["status"])
+try {
+    http.get("URL/loop", map{"max_redirects" -> 2,})
+} catch (e: error) {
+    println(e.code(), e.message().contains("stopped after 2 redirects"))
+}
+`, "/target\n302\nnetwork true")
+}
+
+func TestHttpClientKeepsCookies(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			return
+		}
+		session, err := r.Cookie("session")
+		if err != nil {
+			fmt.Fprint(w, "none")
+			return
+		}
+		fmt.Fprint(w, session.Value)
+	})
+	expect_http_output(t, handler, `
+import http from "http"
+const client = http.client(map{"base_url" -> "URL",})
+println(client.get("/me")["body"])
+println(client.get("/login")["cookies"]["session"])
+println(client.get("/me")["body"])
+println(http.get("URL/me")["body"])
+`, "none\nabc\nabc\nnone")
+}
+
+func TestHttpTimeout(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	expect_http_output(t, handler, `
+import http from "http"
+try {
+    http.get("URL", map{"timeout" -> 50,})
+} catch (e: error) {
+    println(e.code())
+}
+`, "timeout")
+}
+
+func TestHttpMultipart(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("report")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		fmt.Fprintf(w, "%s %s %s %s", r.FormValue("title"), header.Filename,
+			header.Header.Get("Content-Type"), content)
+	})
+	expect_http_output(t, handler, `
+import http from "http"
+const response = http.post("URL", map{"multipart" -> map{
+    "title" -> "report",
+    "report" -> map{"content" -> "a,b", "filename" -> "q1.csv", "content_type" -> "text/csv",},
+},})
+println(response["status"], response["body"])
+`, "200 report q1.csv text/csv a,b")
+}
+
+func TestHttpOptionErrorsAreInvalid(t *testing.T) {
+	expect_output(t, `
+import http from "http"
+const options = []any{
+    map{"retries" -> -1,},
+    map{"headers" -> "x",},
+    map{"body" -> "a", "json" -> 1,},
+    map{"multipart" -> map{"file" -> map{"mode" -> "r",},},},
+    map{"verbose" -> true,},
+}
+for _, option in options {
+    try {
+        http.get("http://localhost", option)
+    } catch (e: error) {
+        println(e.code(), e.message())
+    }
+}
+try {
+    http.client(map{"stream" -> true,})
+} catch (e: error) {
+    println(e.code(), e.message())
+}
+`, `invalid http option 'retries' must be a number that is not negative but got -1
+invalid http option 'headers' must be a map but got string
+invalid http options 'body', 'json', 'form' and 'multipart' cannot be combined
+invalid unknown multipart file option: mode
+invalid unknown http option: verbose
+invalid unknown http client option: stream`)
+}
@@ -0,0 +1,218 @@
+package interpreter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/table-harmony/HarmonyLang/src/lexer"
+	"github.com/table-harmony/HarmonyLang/src/packages"
+	"github.com/table-harmony/HarmonyLang/src/parser"
+)
+
+// module_extension is appended to import paths that name a file without it
+const module_extension = ".harmony"
+
+// module_cache holds the modules of the files imported during a run so that
+// each file is executed once, along with the chain of files being loaded to
+// detect import cycles. Files are identified by their absolute path.
+type module_cache struct {
+	modules map[string]Module
+	loading []string
+}
+
+func new_module_cache() *module_cache {
+	return &module_cache{modules: make(map[string]Module)}
+}
+
+var modules = new_module_cache()
+
+// import_module returns the module an import statement in the given scope
+// refers to. Names without a path separator or extension refer to standard
+// modules first, anything else is a file.
+func import_module(spec string, scope *Scope) Module {
+	if !strings.ContainsAny(spec, `/\`) && filepath.Ext(spec) == "" {
+		if module, exists := standard_modules[spec]; exists {
+			module.name = spec
+			return module
+		}
+	}
+
+	return load_module(resolve_module(spec, scope.File()))
+}
+
+// resolve_module finds the file an import refers to. Paths starting with
+// "./" or "../" are relative to the importing file. Other relative paths are
+// looked up next to the importing file, then in the vendor trees of the
+// enclosing projects, then in each directory of HARMONY_PATH and finally in
+// the working directory. The extension may be left out, and a directory with
+// a manifest resolves to the entry file of the package.
+func resolve_module(spec string, importer string) string {
+	base := "."
+	if importer != "" {
+		base = filepath.Dir(importer)
+	}
+
+	directories := []string{""}
+	switch {
+	case filepath.IsAbs(spec):
+	case strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../"):
+		directories = []string{base}
+	default:
+		directories = []string{base}
+		directories = append(directories, vendor_directories(base)...)
+		for _, directory := range filepath.SplitList(os.Getenv("HARMONY_PATH")) {
+			if directory != "" {
+				directories = append(directories, directory)
+			}
+		}
+		directories = append(directories, ".")
+	}
+
+	names := []string{spec}
+	if filepath.Ext(spec) != module_extension {
+		names = append(names, spec+module_extension)
+	}
+
+	searched := make([]string, 0)
+	for _, directory := range directories {
+		for _, name := range names {
+			candidate := filepath.Join(directory, name)
+			info, err := os.Stat(candidate)
+			if err != nil {
+				continue
+			}
+			if !info.IsDir() {
+				return module_path(candidate)
+			}
+
+			// A package is imported by its name through its entry file
+			if entry, ok := packages.PackageEntry(candidate); ok {
+				return module_path(entry)
+			}
+		}
+		searched = append(searched, module_display_path(module_path(filepath.Join(directory, spec))))
+	}
+
+	panic(fmt.Sprintf("module '%s' not found, searched %s", spec, strings.Join(searched, ", ")))
+}
+
+// vendor_directories lists the vendor trees from dir up to the root of the
+// file system, closest first, so that vendored packages find the packages
+// vendored next to them
+func vendor_directories(dir string) []string {
+	dir = module_path(dir)
+	directories := make([]string, 0)
+	for {
+		vendor := filepath.Join(dir, packages.VendorDir)
+		if info, err := os.Stat(vendor); err == nil && info.IsDir() {
+			directories = append(directories, vendor)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return directories
+		}
+		dir = parent
+	}
+}
+
+// load_module executes a file the first time it is imported during a run and
+// returns its exports
+func load_module(path string) Module {
+	if module, exists := modules.modules[path]; exists {
+		return module
+	}
+
+	for i, loading := range modules.loading {
+		if loading != path {
+			continue
+		}
+
+		chain := make([]string, 0)
+		for _, file := range append(modules.loading[i:], path) {
+			chain = append(chain, module_display_path(file))
+		}
+		panic(fmt.Sprintf("import cycle: %s", strings.Join(chain, " -> ")))
+	}
+
+	modules.loading = append(modules.loading, path)
+	defer func() {
+		modules.loading = modules.loading[:len(modules.loading)-1]
+	}()
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+
+	tokens := lexer.Tokenize(string(file))
+	ast := parser.Parse(tokens)
+	moduleScope := interpret(ast, path)
+
+	module := *NewModule()
+	module.name = module_display_path(path)
+	module.private = make(map[string]bool)
+	builtins := NewRootScope().storage
+	for identifier, ref := range moduleScope.storage {
+		if _, exists := builtins[identifier]; exists {
+			continue
+		}
+
+		switch ref.(type) {
+		case *FunctionReference, *VariableReference, *Struct:
+			if moduleScope.exports[identifier] {
+				module.exports[identifier] = ref
+			} else {
+				module.private[identifier] = true
+			}
+		}
+	}
+
+	modules.modules[path] = module
+	return module
+}
+
+// ImportReference binds a name imported from a file module to the module's
+// own reference, so that importers see the module's current state and
+// assignments go through to it
+type ImportReference struct {
+	identifier string
+	target     Reference
+}
+
+func NewImportReference(identifier string, target Reference) *ImportReference {
+	return &ImportReference{identifier, target}
+}
+
+// ImportReference implements the Value interface
+func (r *ImportReference) Type() Type     { return r.target.Type() }
+func (r *ImportReference) Clone() Value   { return r.target.Clone() }
+func (r *ImportReference) String() string { return r.target.String() }
+
+// ImportReference implements the Reference interface
+func (r *ImportReference) Load() Value         { return r.target.Load() }
+func (r *ImportReference) Store(v Value) error { return r.target.Store(v) }
+func (r *ImportReference) Address() Value      { return r.target.Address() }
+
+// module_path returns the absolute, cleaned path identifying a file
+func module_path(path string) string {
+	if absolute, err := filepath.Abs(path); err == nil {
+		return absolute
+	}
+	return filepath.Clean(path)
+}
+
+// module_display_path shortens a path to be relative to the working
+// directory when it is inside of it
+func module_display_path(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if relative, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(relative, "..") {
+		return relative
+	}
+	return path
+}
@@ -9,18 +9,48 @@ type interpreter struct {
 	pos int
 }
 
+// Interpret runs a program whose imports are resolved against the working
+// directory
 func Interpret(ast []ast.Statement) *Scope {
-	interpreter := create_interpreter(ast)
-	scope := NewRootScope()
+	return InterpretFile(ast, "")
+}
+
+// InterpretFile runs the program read from path, resolving its imports
+// relative to it. Every run starts with an empty module cache.
+func InterpretFile(ast []ast.Statement, path string) *Scope {
+	return interpret(ast, start_program(path))
+}
 
+// start_program clears what a previous run left behind and returns the path
+// the program is known by to the modules it imports
+func start_program(path string) string {
 	load_native_modules()
 
+	modules = new_module_cache()
+	exit_hooks = make([]Function, 0)
+	if path != "" {
+		path = module_path(path)
+		modules.loading = append(modules.loading, path)
+	}
+	return path
+}
+
+func interpret(ast []ast.Statement, file string) *Scope {
+	scope := NewRootScope()
+	scope.file = file
+	defer scope.RunDeferred()
+
+	evaluate_program(ast, scope)
+	return scope
+}
+
+// evaluate_program runs the top level statements of a file in its scope
+func evaluate_program(ast []ast.Statement, scope *Scope) {
+	interpreter := create_interpreter(ast)
 	for !interpreter.is_empty() {
 		interpreter.evalute_current_statement(scope)
 		interpreter.advance(1)
 	}
-
-	return scope
 }
 
 func create_interpreter(ast []ast.Statement) *interpreter {
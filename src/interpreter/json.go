@@ -0,0 +1,684 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// json_field returns the key a struct field is encoded under, whether it is
+// left out when empty and whether it is skipped entirely. The @json tag takes
+// the key as a string and the omitempty option, a key of "-" skips the field.
+func json_field(name string, attr StructAttribute) (key string, omitEmpty bool, skip bool) {
+	key = name
+	for _, argument := range attr.tags["json"] {
+		switch argument {
+		case "-":
+			return name, false, true
+		case "omitempty":
+			omitEmpty = true
+		case "":
+		default:
+			key = argument
+		}
+	}
+	return key, omitEmpty, false
+}
+
+// json_has_key reports whether the @json tag of a field renames it
+func json_has_key(attr StructAttribute) bool {
+	for _, argument := range attr.tags["json"] {
+		if argument != "-" && argument != "omitempty" && argument != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// json_is_empty reports whether a value is left out by the omitempty option
+func json_is_empty(value Value) bool {
+	switch value := value.(type) {
+	case Nil:
+		return true
+	case Boolean:
+		return !value.Value()
+	case Number:
+		return value.Value() == 0
+	case String:
+		return value.Value() == ""
+	case Slice:
+		return len(*value.elements) == 0
+	case Map:
+		return len(*value.entries) == 0
+	case *Pointer:
+		return value.target == nil
+	default:
+		return false
+	}
+}
+
+// json_member is a key of a JSON object along with its value
+type json_member struct {
+	key   string
+	value Value
+}
+
+// struct_json_members returns the members of the JSON object an instance is
+// encoded as, sorted by field name. The fields of embedded structs are promoted
+// into the object unless the embedded field is tagged with a key, fields of the
+// outer struct win.
+func struct_json_members(instance StructInstantiation) []json_member {
+	_type := instance.constructor._type
+	embedded := make(map[string]bool)
+	for _, name := range _type.embedded {
+		embedded[name] = true
+	}
+
+	names := make([]string, 0, len(instance.storage))
+	for name := range instance.storage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	members := make([]json_member, 0, len(names))
+	promoted := make([]json_member, 0)
+	for _, name := range names {
+		attr := _type.storage[name]
+		key, omitEmpty, skip := json_field(name, attr)
+		if skip {
+			continue
+		}
+
+		value := instance.storage[name].Load()
+		if embedded[name] && !json_has_key(attr) {
+			if inner, ok := value.(StructInstantiation); ok {
+				promoted = append(promoted, struct_json_members(inner)...)
+			}
+			continue
+		}
+
+		if omitEmpty && json_is_empty(value) {
+			continue
+		}
+		members = append(members, json_member{key, value})
+	}
+
+	declared := make(map[string]bool)
+	for _, member := range members {
+		declared[member.key] = true
+	}
+	for _, member := range promoted {
+		if !declared[member.key] {
+			declared[member.key] = true
+			members = append(members, member)
+		}
+	}
+
+	return members
+}
+
+// struct_to_native converts an instance to a map keyed by the JSON names of
+// its fields
+func struct_to_native(instance StructInstantiation) map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, member := range struct_json_members(instance) {
+		result[member.key] = convert_to_native(member.value)
+	}
+	return result
+}
+
+// json_kind names the kind of a decoded JSON value for error messages
+func json_kind(native interface{}) string {
+	switch native.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", native)
+	}
+}
+
+// throw_json_error throws an invalid error describing where in the document
+// decoding failed
+func throw_json_error(path string, format string, args ...any) {
+	panic(NewThrowError(NewStructuredError(
+		fmt.Sprintf("json: %s at %s", fmt.Sprintf(format, args...), path),
+		ErrorCodeInvalid,
+		nil,
+		[]MapEntry{{key: NewString("path"), value: NewString(path)}},
+	)))
+}
+
+// json_decode converts a decoded JSON value into a value of the target type.
+// Null decodes to the default value of the type.
+func json_decode(native interface{}, target Type, path string) Value {
+	if value, ok := target.(ValueType); ok {
+		target = value._type
+	}
+	if native == nil {
+		return target.DefaultValue()
+	}
+
+	mismatch := func() {
+		throw_json_error(path, "cannot decode %s into %v", json_kind(native), target)
+	}
+
+	switch _type := target.(type) {
+	case PrimitiveType:
+		value := convert_to_value(native)
+		if _type.kind != AnyType && !_type.Equals(value.Type()) {
+			mismatch()
+		}
+		return value
+
+	case *SliceType:
+		return json_decode(native, *_type, path)
+	case SliceType:
+		elements, ok := native.([]interface{})
+		if !ok {
+			mismatch()
+		}
+		values := make([]Value, len(elements))
+		for i, element := range elements {
+			values[i] = json_decode(element, _type.elementType, fmt.Sprintf("%s[%d]", path, i))
+		}
+		return NewSlice(values, _type.elementType)
+
+	case ArrayType:
+		elements, ok := native.([]interface{})
+		if !ok {
+			mismatch()
+		}
+		if len(elements) != _type.size {
+			throw_json_error(path, "cannot decode an array of %d elements into %v", len(elements), _type)
+		}
+		values := make([]Value, len(elements))
+		for i, element := range elements {
+			values[i] = json_decode(element, _type.elementType, fmt.Sprintf("%s[%d]", path, i))
+		}
+		return NewArray(values, NewNumber(float64(_type.size)), _type.elementType)
+
+	case *MapType:
+		return json_decode(native, *_type, path)
+	case MapType:
+		object, ok := native.(map[string]interface{})
+		if !ok {
+			mismatch()
+		}
+		if !_type.keyType.Equals(PrimitiveType{StringType}) {
+			throw_json_error(path, "cannot decode an object into %v, keys must be strings", _type)
+		}
+
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		entries := make([]MapEntry, len(keys))
+		for i, key := range keys {
+			entries[i] = MapEntry{
+				key:   NewString(key),
+				value: json_decode(object[key], _type.valueType, path+"."+key),
+			}
+		}
+		return NewMap(entries, _type.keyType, _type.valueType)
+
+	case *PointerType:
+		return json_decode(native, *_type, path)
+	case PointerType:
+		value := json_decode(native, _type.valueType, path)
+		return NewPointer(NewVariableReference("", false, value, _type.valueType))
+
+	case StructType:
+		object, ok := native.(map[string]interface{})
+		if !ok {
+			mismatch()
+		}
+		return json_decode_struct(object, _type, path)
+
+	default:
+		throw_json_error(path, "cannot decode into %v", target)
+		return nil
+	}
+}
+
+// json_decode_struct creates an instance of a struct from a JSON object.
+// Unknown keys are ignored and missing fields keep their default value.
+func json_decode_struct(object map[string]interface{}, _type StructType, path string) Value {
+	instance := NewStructInstance(&Struct{identifier: _type.identifier, _type: _type})
+	embedded := make(map[string]bool)
+	for _, name := range _type.embedded {
+		embedded[name] = true
+	}
+
+	for name, field := range instance.storage {
+		attr := _type.storage[name]
+		key, _, skip := json_field(name, attr)
+		if skip {
+			continue
+		}
+
+		var value Value
+		if embedded[name] && !json_has_key(attr) {
+			// The fields of an embedded struct are read from the same object
+			value = json_decode_struct(object, field.Type().(StructType), path)
+		} else {
+			native, exists := object[key]
+			if !exists {
+				if attr.isRequired {
+					throw_json_error(path, "missing required field '%s'", key)
+				}
+				continue
+			}
+			value = json_decode(native, field.Type(), path+"."+key)
+		}
+
+		if err := field.Store(value); err != nil {
+			throw_json_error(path+"."+key, "%v", err)
+		}
+	}
+
+	return instance
+}
+
+// json_options controls how values are encoded
+type json_options struct {
+	indent     string
+	sortKeys   bool
+	escapeHTML bool
+}
+
+// json_stringify_options reads the options map of stringify. The indent may
+// be a number of spaces or a string.
+func json_stringify_options(options Value) json_options {
+	result := json_options{escapeHTML: true}
+	if options == nil {
+		return result
+	}
+
+	opts, ok := options.(Map)
+	if !ok {
+		panic(fmt.Sprintf("json options must be a map but got %v", options.Type()))
+	}
+	for _, entry := range *opts.entries {
+		switch entry.key.String() {
+		case "indent":
+			switch indent := entry.value.(type) {
+			case Number:
+				result.indent = strings.Repeat(" ", int(indent.Value()))
+			case String:
+				result.indent = indent.Value()
+			default:
+				panic(fmt.Sprintf("json indent must be a number or a string but got %v", entry.value.Type()))
+			}
+		case "sort_keys":
+			result.sortKeys = expect_option[Boolean]("json", "sort_keys", entry.value).Value()
+		case "escape_html":
+			result.escapeHTML = expect_option[Boolean]("json", "escape_html", entry.value).Value()
+		default:
+			panic(fmt.Sprintf("unknown json option: %s", entry.key.String()))
+		}
+	}
+	return result
+}
+
+// json_stringify encodes a value as JSON. Map keys keep their insertion order
+// unless sortKeys is set, struct fields are sorted by name.
+func json_stringify(value Value, options json_options) string {
+	var builder strings.Builder
+	json_write(&builder, value, options, 0, "$")
+	return builder.String()
+}
+
+func json_write(builder *strings.Builder, value Value, options json_options, depth int, path string) {
+	newline := func(depth int) {
+		if options.indent != "" {
+			builder.WriteString("\n" + strings.Repeat(options.indent, depth))
+		}
+	}
+
+	writeArray := func(elements []Value) {
+		if len(elements) == 0 {
+			builder.WriteString("[]")
+			return
+		}
+		builder.WriteString("[")
+		for i, element := range elements {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			newline(depth + 1)
+			json_write(builder, element, options, depth+1, fmt.Sprintf("%s[%d]", path, i))
+		}
+		newline(depth)
+		builder.WriteString("]")
+	}
+
+	writeObject := func(members []json_member) {
+		if len(members) == 0 {
+			builder.WriteString("{}")
+			return
+		}
+		if options.sortKeys {
+			sort.SliceStable(members, func(i, j int) bool { return members[i].key < members[j].key })
+		}
+		builder.WriteString("{")
+		for i, member := range members {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			newline(depth + 1)
+			builder.WriteString(json_quote(member.key, options.escapeHTML))
+			builder.WriteString(":")
+			if options.indent != "" {
+				builder.WriteString(" ")
+			}
+			json_write(builder, member.value, options, depth+1, path+"."+member.key)
+		}
+		newline(depth)
+		builder.WriteString("}")
+	}
+
+	switch value := value.(type) {
+	case Reference:
+		json_write(builder, value.Load(), options, depth, path)
+	case Nil:
+		builder.WriteString("null")
+	case Boolean:
+		builder.WriteString(strconv.FormatBool(value.Value()))
+	case Number:
+		if math.IsNaN(value.Value()) || math.IsInf(value.Value(), 0) {
+			throw_json_error(path, "cannot encode %v", value)
+		}
+		number, _ := json.Marshal(value.Value())
+		builder.Write(number)
+	case String:
+		builder.WriteString(json_quote(value.Value(), options.escapeHTML))
+	case Slice:
+		writeArray(*value.elements)
+	case Array:
+		writeArray(value.elements)
+	case Map:
+		members := make([]json_member, 0, len(*value.entries))
+		for _, entry := range *value.entries {
+			switch key := entry.key.(type) {
+			case String:
+				members = append(members, json_member{key.Value(), entry.value})
+			case Number:
+				members = append(members, json_member{format_value(key, false), entry.value})
+			default:
+				throw_json_error(path, "cannot encode a map with %v keys", entry.key.Type())
+			}
+		}
+		writeObject(members)
+	case StructInstantiation:
+		writeObject(struct_json_members(value))
+	case *Pointer:
+		if value.target == nil {
+			builder.WriteString("null")
+			return
+		}
+		json_write(builder, value.target.Load(), options, depth, path)
+	default:
+		throw_json_error(path, "cannot encode a value of type %v", value.Type())
+	}
+}
+
+// json_quote encodes a string as a JSON string, escaping <, > and & when
+// escapeHTML is set
+func json_quote(str string, escapeHTML bool) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(escapeHTML)
+	encoder.Encode(str)
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// max_safe_integer is the largest integer a number holds exactly
+const max_safe_integer = 1 << 53
+
+// json_reader parses JSON values from a stream of tokens, keeping the order of
+// object keys and the exact text of numbers
+type json_reader struct {
+	text       string
+	decoder    *json.Decoder
+	bigNumbers bool
+	// line is the line of the input the text starts on
+	line int
+}
+
+func new_json_reader(text string, bigNumbers bool) *json_reader {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	return &json_reader{text, decoder, bigNumbers, 1}
+}
+
+// json_parse_options reads the options map of parse
+func json_parse_options(options Value) bool {
+	bigNumbers := false
+	if options == nil {
+		return bigNumbers
+	}
+
+	opts, ok := options.(Map)
+	if !ok {
+		panic(fmt.Sprintf("json options must be a map but got %v", options.Type()))
+	}
+	for _, entry := range *opts.entries {
+		switch entry.key.String() {
+		case "big_numbers":
+			switch mode := entry.value.String(); mode {
+			case "string":
+				bigNumbers = true
+			case "number":
+				bigNumbers = false
+			default:
+				panic(fmt.Sprintf("json option 'big_numbers' must be \"string\" or \"number\" but got %s", mode))
+			}
+		default:
+			panic(fmt.Sprintf("unknown json option: %s", entry.key.String()))
+		}
+	}
+	return bigNumbers
+}
+
+// position returns the line and column of an offset in the text
+func (r *json_reader) position(offset int64) (int, int) {
+	offset = min(offset, int64(len(r.text)))
+	before := r.text[:offset]
+	line := strings.Count(before, "\n") + r.line
+	column := int(offset) - strings.LastIndex(before, "\n")
+	return line, column
+}
+
+// next_offset returns the offset of the next token
+func (r *json_reader) next_offset() int64 {
+	offset := r.decoder.InputOffset()
+	rest := r.text[offset:]
+	return offset + int64(len(rest)-len(strings.TrimLeft(rest, " \t\r\n")))
+}
+
+// fail throws a syntax error pointing at the position the reader stopped at
+func (r *json_reader) fail(err error) {
+	r.fail_at(err, r.decoder.InputOffset())
+}
+
+// fail_at throws a syntax error pointing at the offset unless the error
+// carries its own position
+func (r *json_reader) fail_at(err error, offset int64) {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// The offset of a syntax error is just past the offending character
+		offset = max(syntaxErr.Offset-1, 0)
+	} else if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		offset = int64(len(r.text))
+		err = errors.New("unexpected end of JSON input")
+	}
+
+	line, column := r.position(offset)
+	panic(NewThrowError(NewStructuredError(
+		fmt.Sprintf("JSON parse error: %v at line %d, column %d", err, line, column),
+		ErrorCodeSyntax,
+		nil,
+		[]MapEntry{
+			{key: NewString("offset"), value: NewNumber(float64(offset))},
+			{key: NewString("line"), value: NewNumber(float64(line))},
+			{key: NewString("column"), value: NewNumber(float64(column))},
+		},
+	)))
+}
+
+func (r *json_reader) token() json.Token {
+	token, err := r.decoder.Token()
+	if err != nil {
+		r.fail(err)
+	}
+	return token
+}
+
+// more reports whether another top level value follows
+func (r *json_reader) more() bool {
+	return r.decoder.More()
+}
+
+// read parses the next value
+func (r *json_reader) read() Value {
+	switch token := r.token().(type) {
+	case json.Delim:
+		if token == '[' {
+			elements := make([]Value, 0)
+			for r.decoder.More() {
+				elements = append(elements, r.read())
+			}
+			r.token()
+			return NewSlice(elements, PrimitiveType{AnyType})
+		}
+
+		entries := make([]MapEntry, 0)
+		for r.decoder.More() {
+			key := r.token().(string)
+			entries = append(entries, MapEntry{key: NewString(key), value: r.read()})
+		}
+		r.token()
+		return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{AnyType})
+	case json.Number:
+		return r.number(token)
+	case string:
+		return NewString(token)
+	case bool:
+		return NewBoolean(token)
+	default:
+		return NewNil()
+	}
+}
+
+// number converts a number, integers too large to be held exactly are kept as
+// strings when bigNumbers is set
+func (r *json_reader) number(number json.Number) Value {
+	if integer, err := number.Int64(); err == nil {
+		if (integer > max_safe_integer || integer < -max_safe_integer) && r.bigNumbers {
+			return NewString(number.String())
+		}
+		return NewNumber(float64(integer))
+	}
+
+	if r.bigNumbers && !strings.ContainsAny(number.String(), ".eE") {
+		return NewString(number.String())
+	}
+
+	float, err := number.Float64()
+	if err != nil {
+		r.fail(err)
+	}
+	return NewNumber(float)
+}
+
+// json_parse parses a single JSON document
+func json_parse(text string, bigNumbers bool) Value {
+	return new_json_reader(text, bigNumbers).read_document()
+}
+
+// read_document parses the only value of the text
+func (r *json_reader) read_document() Value {
+	value := r.read()
+
+	offset := r.next_offset()
+	if _, err := r.decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("invalid data after top-level value")
+		}
+		r.fail_at(err, offset)
+	}
+	return value
+}
+
+// json_parse_lines parses JSON Lines as they are read, one value per line
+// with blank lines skipped. Each value is passed to the callback along with
+// its line if there is one, otherwise the values are returned.
+func json_parse_lines(next func() (string, bool), callback Function) []Value {
+	values := make([]Value, 0)
+	for number := 1; ; number++ {
+		line, ok := next()
+		if !ok {
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		reader := new_json_reader(line, false)
+		reader.line = number
+		value := reader.read_document()
+		if callback == nil {
+			values = append(values, value)
+			continue
+		}
+
+		args := []Value{value, NewNumber(float64(number))}
+		if _, err := callback.Call(args[:min(function_arity(callback), len(args))]...); err != nil {
+			panic(err)
+		}
+	}
+	return values
+}
+
+// json_lines_source reads the lines of a string, a read stream or a file
+func json_lines_source(input Value) func() (string, bool) {
+	switch input := input.(type) {
+	case String:
+		lines := strings.Split(input.Value(), "\n")
+		return func() (string, bool) {
+			if len(lines) == 0 {
+				return "", false
+			}
+			line := strings.TrimSuffix(lines[0], "\r")
+			lines = lines[1:]
+			return line, true
+		}
+	case *Stream:
+		if input.reader == nil {
+			throw_error(ErrorCodeInvalid, "cannot read from %v", input)
+		}
+		return input.read_line
+	case *File:
+		return input.read_line
+	}
+	throw_error(ErrorCodeInvalid, "parse_lines expects a string, stream or file but got %v", input.Type())
+	return nil
+}
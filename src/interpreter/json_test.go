@@ -0,0 +1,46 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJsonParseLinesReadsFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	data := "{\"type\": \"start\"}\n\n{\"type\": \"stop\"}\r\n{\"type\": \n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expect_output(t, strings.ReplaceAll(`
+import os from "os"
+import json from "json"
+import fmt from "fmt"
+const events = os.open("EVENTS")
+try {
+  json.parse_lines(events, fn(event, line) { fmt.println(line, event["type"]) })
+} catch (e: error) {
+  fmt.println(e.code(), e.fields()["line"])
+}
+`, "EVENTS", filepath.ToSlash(path)), "1 start\n3 stop\nsyntax 4")
+}
+
+func TestJsonParseLinesReadsStrings(t *testing.T) {
+	expect_output(t, `
+import json from "json"
+import fmt from "fmt"
+const values = json.parse_lines("1
+
+[2, 3]
+true
+")
+fmt.println(values.len(), values[1][0], values[2])
+try {
+  json.parse_lines(1)
+} catch (e: error) {
+  fmt.println(e.code())
+}
+`, "3 2 true\ninvalid")
+}
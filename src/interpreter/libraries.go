@@ -2,53 +2,57 @@ package interpreter
 
 import (
 	"fmt"
-	"math/rand"
+	"io"
+	"os"
 	"strconv"
-
-	"github.com/table-harmony/HarmonyLang/src/helpers"
 )
 
-var native_print = NewNativeFunction(print_function, []Type{PrimitiveType{AnyType}}, PrimitiveType{NilType})
+var native_print = NewVariadicNativeFunction(print_function, []Type{PrimitiveType{AnyType}}, PrimitiveType{NilType})
 
 func print_function(args ...Value) Value {
-	for i, arg := range args {
-		if i > 0 {
-			fmt.Print(" ")
-		}
-		processed := helpers.ProcessEscapes(arg.String())
-		fmt.Print(processed)
-	}
+	write_values(os.Stdout, args...)
 	return NewNil()
 }
 
-var native_println = NewNativeFunction(println_function, []Type{PrimitiveType{AnyType}}, PrimitiveType{NilType})
+var native_println = NewVariadicNativeFunction(println_function, []Type{PrimitiveType{AnyType}}, PrimitiveType{NilType})
 
 func println_function(args ...Value) Value {
-	for i, arg := range args {
-		if i > 0 {
-			fmt.Print(" ")
-		}
-
-		processed := helpers.ProcessEscapes(arg.String())
-		fmt.Println(processed)
-	}
-	fmt.Print("\n")
+	write_values(os.Stdout, args...)
+	fmt.Fprint(os.Stdout, "\n")
 	return NewNil()
 }
 
-var native_printf = NewNativeFunction(printf_function, []Type{PrimitiveType{AnyType}}, PrimitiveType{NilType})
+var native_printf = NewVariadicNativeFunction(printf_function, []Type{PrimitiveType{StringType}, PrimitiveType{AnyType}}, PrimitiveType{NilType})
 
 func printf_function(args ...Value) Value {
-	for _, arg := range args {
-		if rand.Intn(100) == 42 {
-			fmt.Println("🎉 YOU FOUND THE SECRET MESSAGE! 🎉")
-		}
+	fmt.Fprint(os.Stdout, sprintf_function(args...).(String).Value())
+	return NewNil()
+}
+
+var native_sprintf = NewVariadicNativeFunction(sprintf_function, []Type{PrimitiveType{StringType}, PrimitiveType{AnyType}}, PrimitiveType{StringType})
+
+func sprintf_function(args ...Value) Value {
+	return NewString(format_string(args[0].(String).Value(), args[1:]))
+}
 
-		processed := helpers.ProcessEscapes(arg.String())
-		fmt.Printf("💥🌟💥 %s 💥🌟💥\n", processed)
+// write_values writes values separated by spaces the way print does
+func write_values(writer io.Writer, args ...Value) {
+	for i, arg := range args {
+		if i > 0 {
+			fmt.Fprint(writer, " ")
+		}
+		fmt.Fprint(writer, arg.String())
 	}
+}
 
-	return NewNil()
+// expect_option returns the value of an option of a library, which must be
+// of type T
+func expect_option[T Value](library string, name string, value Value) T {
+	option, ok := value.(T)
+	if !ok {
+		panic(fmt.Sprintf("%s option '%s' has the wrong type %v", library, name, value.Type()))
+	}
+	return option
 }
 
 var native_string = NewNativeFunction(string_function, []Type{PrimitiveType{AnyType}}, PrimitiveType{StringType})
@@ -104,7 +108,7 @@ func number_function(args ...Value) Value {
 	case String:
 		outcome, err := strconv.ParseFloat(value.Value(), 64)
 		if err != nil {
-			panic(fmt.Errorf("cannot convert '%s' to number", value.Value()))
+			throw_error(ErrorCodeInvalid, "cannot convert '%s' to number", value.Value())
 		}
 		return NewNumber(outcome)
 	case Nil:
@@ -114,8 +118,15 @@ func number_function(args ...Value) Value {
 	}
 }
 
-var native_error = NewNativeFunction(error_function, []Type{PrimitiveType{StringType}}, PrimitiveType{ErrorType})
+var native_error = NewVariadicNativeFunction(error_function, []Type{PrimitiveType{StringType}, PrimitiveType{AnyType}}, PrimitiveType{ErrorType})
 
 func error_function(args ...Value) Value {
-	return NewError(args[0].(String).Value())
+	switch len(args) {
+	case 1:
+		return error_options(args[0].(String).Value(), nil)
+	case 2:
+		return error_options(args[0].(String).Value(), args[1])
+	default:
+		panic(fmt.Sprintf("error() expects a message and an optional options map but got %d arguments", len(args)))
+	}
 }
@@ -35,8 +35,10 @@ func create_lookups() {
 	register_statement_handler[ast.FunctionDeclarationStatment](evaluate_function_declaration_statement)
 	register_statement_handler[ast.AssignmentStatement](evaluate_assignment_statement)
 	register_statement_handler[ast.ThrowStatement](evaluate_throw_statement)
+	register_statement_handler[ast.DeferStatement](evaluate_defer_statement)
 	register_statement_handler[ast.TypeDeclarationStatement](evaluate_type_declaration_statement)
 	register_statement_handler[ast.ImportStatement](evaluate_import_statement)
+	register_statement_handler[ast.ExportStatement](evaluate_export_statement)
 	register_statement_handler[ast.StructDeclarationStatement](evaluate_struct_declaration_statement)
 
 	// Expressions
@@ -50,6 +52,7 @@ func create_lookups() {
 	register_expression_handler[ast.MemberExpression](evaluate_member_expression)
 	register_expression_handler[ast.RangeExpression](evaluate_range_expression)
 	register_expression_handler[ast.StructLiteralExpression](evaluate_struct_instantiation_expression)
+	register_expression_handler[ast.ConstructorCallExpression](evaluate_constructor_call_expression)
 
 	// Block expressions
 	register_expression_handler[ast.BlockExpression](evaluate_block_expression)
@@ -31,6 +31,11 @@ func (m MapType) Equals(other Type) bool {
 		return primitive.kind == NilType
 	}
 
+	// Types built by the constructor are pointers, compare them by value
+	if ptr, ok := other.(*MapType); ok && ptr != nil {
+		other = *ptr
+	}
+
 	otherMap, ok := other.(MapType)
 	if !ok {
 		return false
@@ -93,10 +98,16 @@ func (m Map) String() string {
 	return str
 }
 
+// map_keys_equal reports whether two keys are the same key: values of the
+// same type that are equal by the rules of ==
+func map_keys_equal(left, right Value) bool {
+	return left.Type().Equals(right.Type()) && evaluate_equals(left, right).(Boolean).Value()
+}
+
 // Map specific methods
 func (m *Map) Get(key Value) Value {
 	for _, entry := range *(m.entries) {
-		if entry.key.String() == key.String() {
+		if map_keys_equal(entry.key, key) {
 			return entry.value
 		}
 	}
@@ -113,7 +124,7 @@ func (m *Map) Set(key Value, newValue Value) {
 	}
 
 	for i, entry := range *m.entries {
-		if entry.key == key {
+		if map_keys_equal(entry.key, key) {
 			(*m.entries)[i].value = newValue
 			return
 		}
@@ -138,7 +149,7 @@ func (m *Map) Values() []Value {
 }
 func (m *Map) IsExist(key Value) bool {
 	for _, entry := range *(m.entries) {
-		if entry.key == key {
+		if map_keys_equal(entry.key, key) {
 			return true
 		}
 	}
@@ -148,7 +159,7 @@ func (m *Map) Pop(key Value) Value {
 	index := -1
 
 	for i, entry := range *(m.entries) {
-		if entry.key == key {
+		if map_keys_equal(entry.key, key) {
 			index = i
 			break
 		}
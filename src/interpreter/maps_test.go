@@ -0,0 +1,16 @@
+package interpreter
+
+import "testing"
+
+func TestMapKeysOfDifferentTypesAreDistinct(t *testing.T) {
+	expect_output(t, `
+import fmt from "fmt"
+let m = map[any -> number]{}
+m[1] = 10
+m["1"] = 20
+m[1] = 11
+fmt.println(m[1], m["1"], m.exists(1), m.exists("1"), m.exists(true))
+m.pop("1")
+fmt.println(m[1], m.exists("1"))
+`, "11 20 true true false\n11 false")
+}
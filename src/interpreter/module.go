@@ -2,15 +2,15 @@ package interpreter
 
 import (
 	"encoding/json"
-	"encoding/xml"
+	"errors"
 	"fmt"
+	"io/fs"
 	"math"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
-
-	"github.com/table-harmony/HarmonyLang/src/helpers"
 )
 
 type ModuleType struct {
@@ -27,6 +27,10 @@ func (m ModuleType) DefaultValue() Value {
 
 type Module struct {
 	exports map[string]Value
+	name    string
+	// private holds the top level names of a file module that are not
+	// exported, to tell them apart from names that do not exist
+	private map[string]bool
 }
 
 func NewModule() *Module {
@@ -40,11 +44,20 @@ func (m Module) Type() Type {
 	return ModuleType{}
 }
 func (m Module) Clone() Value {
-	module := NewModule()
-	for key, value := range m.exports {
-		module.exports[key] = value.Clone()
+	// A module is a namespace, copies share its members so that values
+	// changed by the module are seen through every alias of it
+	return &Module{exports: m.exports, name: m.name, private: m.private}
+}
+
+// Member returns an exported member of the module
+func (m Module) Member(name string) Value {
+	if value, exists := m.exports[name]; exists {
+		return value
+	}
+	if m.private[name] {
+		panic(fmt.Sprintf("'%s' is not exported by module '%s'", name, m.name))
 	}
-	return module
+	panic(fmt.Sprintf("module '%s' has no member '%s'", m.name, name))
 }
 func (m Module) String() string {
 	str := "module {\n"
@@ -198,6 +211,28 @@ func init_math_module() Module {
 	return *module
 }
 
+func init_fmt_module() Module {
+	module := NewModule()
+
+	// print(...values: any): nil
+	// Purpose: Prints the values separated by spaces
+	module.exports["print"] = native_print
+
+	// println(...values: any): nil
+	// Purpose: Prints the values separated by spaces followed by a newline
+	module.exports["println"] = native_println
+
+	// printf(format: string, ...values: any): nil
+	// Purpose: Prints the values formatted according to the format verbs
+	module.exports["printf"] = native_printf
+
+	// sprintf(format: string, ...values: any): string
+	// Purpose: Returns the values formatted according to the format verbs
+	module.exports["sprintf"] = native_sprintf
+
+	return *module
+}
+
 func init_time_module() Module {
 	module := NewModule()
 
@@ -412,7 +447,7 @@ func init_os_module() Module {
 			value := args[1].(String).Value()
 			err := os.Setenv(key, value)
 			if err != nil {
-				panic(err.Error())
+				throw_native_error("os.setenv", err)
 			}
 			return NewNil()
 		},
@@ -429,7 +464,7 @@ func init_os_module() Module {
 			path := args[0].(String).Value()
 			data, err := os.ReadFile(path)
 			if err != nil {
-				panic(err.Error())
+				throw_native_error("", err)
 			}
 			return NewString(string(data))
 		},
@@ -445,7 +480,7 @@ func init_os_module() Module {
 			data := args[1].(String).Value()
 			err := os.WriteFile(path, []byte(data), 0644)
 			if err != nil {
-				panic(err.Error())
+				throw_native_error("", err)
 			}
 			return NewNil()
 		},
@@ -460,7 +495,7 @@ func init_os_module() Module {
 			path := args[0].(String).Value()
 			err := os.Remove(path)
 			if err != nil {
-				panic(err.Error())
+				throw_native_error("", err)
 			}
 			return NewNil()
 		},
@@ -475,7 +510,7 @@ func init_os_module() Module {
 			path := args[0].(String).Value()
 			err := os.MkdirAll(path, 0755)
 			if err != nil {
-				panic(err.Error())
+				throw_native_error("", err)
 			}
 			return NewNil()
 		},
@@ -490,7 +525,7 @@ func init_os_module() Module {
 			path := args[0].(String).Value()
 			entries, err := os.ReadDir(path)
 			if err != nil {
-				panic(err.Error())
+				throw_native_error("", err)
 			}
 
 			fileNames := make([]Value, 0)
@@ -511,7 +546,7 @@ func init_os_module() Module {
 			path := args[0].(String).Value()
 			absPath, err := filepath.Abs(path)
 			if err != nil {
-				panic(err.Error())
+				throw_native_error("os.abs_path", err)
 			}
 			return NewString(absPath)
 		},
@@ -519,11 +554,169 @@ func init_os_module() Module {
 		PrimitiveType{StringType},
 	)
 
+	// append_file(path: string, data: string): nil
+	// Purpose: Appends data to a file, creating it if it doesn't exist
+	module.exports["append_file"] = NewNativeFunction(
+		func(args ...Value) Value {
+			file, err := os.OpenFile(args[0].(String).Value(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if err == nil {
+				_, err = file.WriteString(args[1].(String).Value())
+				if closeErr := file.Close(); err == nil {
+					err = closeErr
+				}
+			}
+			if err != nil {
+				throw_native_error("", err)
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{StringType}},
+		PrimitiveType{NilType},
+	)
+
+	// copy(source: string, destination: string): nil
+	// Purpose: Copies a file with its permissions, replacing the destination
+	module.exports["copy"] = NewNativeFunction(
+		func(args ...Value) Value {
+			if err := copy_file(args[0].(String).Value(), args[1].(String).Value()); err != nil {
+				throw_native_error("", err)
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{StringType}},
+		PrimitiveType{NilType},
+	)
+
+	// rename(source: string, destination: string): nil
+	// Purpose: Renames or moves a file or directory
+	module.exports["rename"] = NewNativeFunction(
+		func(args ...Value) Value {
+			if err := os.Rename(args[0].(String).Value(), args[1].(String).Value()); err != nil {
+				throw_native_error("", err)
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{StringType}},
+		PrimitiveType{NilType},
+	)
+
+	// exists(path: string): bool
+	// Purpose: Reports whether a file or directory exists
+	module.exports["exists"] = NewNativeFunction(
+		func(args ...Value) Value {
+			_, err := os.Stat(args[0].(String).Value())
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				throw_native_error("", err)
+			}
+			return NewBoolean(err == nil)
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{BooleanType},
+	)
+
+	// stat(path: string): map[string -> any]
+	// Purpose: Describes a file by its name, size, mode, modified time in Unix seconds and is_dir
+	module.exports["stat"] = NewNativeFunction(
+		func(args ...Value) Value {
+			info, err := os.Stat(args[0].(String).Value())
+			if err != nil {
+				throw_native_error("", err)
+			}
+			return file_info_map(info)
+		},
+		[]Type{PrimitiveType{StringType}},
+		NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
+	)
+
+	// glob(pattern: string): []string
+	// Purpose: Returns the paths matching a pattern, ** matches any number of directories
+	module.exports["glob"] = NewNativeFunction(
+		func(args ...Value) Value {
+			matches, err := glob(args[0].(String).Value())
+			if err != nil {
+				throw_native_error("", err)
+			}
+
+			paths := make([]Value, len(matches))
+			for i, match := range matches {
+				paths[i] = NewString(match)
+			}
+			return NewSlice(paths, PrimitiveType{StringType})
+		},
+		[]Type{PrimitiveType{StringType}},
+		NewSliceType(PrimitiveType{StringType}),
+	)
+
+	// walk(root: string, callback: fn(path, info?)): nil
+	// Purpose: Calls the callback for the root and everything below it in lexical order, stopping when it returns false
+	module.exports["walk"] = NewNativeFunction(
+		func(args ...Value) Value {
+			callback, ok := args[1].(Function)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "expected a callback function but got %v", args[1].Type())
+			}
+
+			err := filepath.WalkDir(args[0].(String).Value(), func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				args := []Value{NewString(path)}
+				if function_arity(callback) > 1 {
+					info, err := entry.Info()
+					if err != nil {
+						return err
+					}
+					args = append(args, file_info_map(info))
+				}
+
+				result, err := callback.Call(args...)
+				if err != nil {
+					panic(err)
+				}
+				if stop, ok := result.(Boolean); ok && !stop.Value() {
+					return filepath.SkipAll
+				}
+				return nil
+			})
+			if err != nil {
+				throw_native_error("", err)
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	// File handles
+
+	// open(path: string, mode?: string): file
+	// Purpose: Opens a file for streaming reads and writes, mode is "r" (default), "r+", "w", "w+", "a", "a+", "x" or "x+"
+	module.exports["open"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			mode := "r"
+			if len(args) > 1 {
+				value, ok := args[1].(String)
+				if !ok {
+					throw_error(ErrorCodeInvalid, "open expects a mode string but got %v", args[1].Type())
+				}
+				mode = value.Value()
+			}
+			return OpenFile(args[0].(String).Value(), mode)
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		FileType{},
+	)
+
 	// exit(code: number): nil
-	// Purpose: Terminates the current process with the specified exit code
+	// Purpose: Runs the exit hooks and terminates the current process with the specified exit code
 	module.exports["exit"] = NewNativeFunction(
 		func(args ...Value) Value {
 			code := int(args[0].(Number).Value())
+			if err := run_exit_hooks(code); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			close_files()
 			os.Exit(code)
 			return NewNil()
 		},
@@ -531,98 +724,348 @@ func init_os_module() Module {
 		PrimitiveType{NilType},
 	)
 
+	// on_exit(callback: fn(code?)): nil
+	// Purpose: Registers a callback run when the program ends, normally, by an uncaught error or by exit, the last registered runs first
+	module.exports["on_exit"] = NewNativeFunction(
+		func(args ...Value) Value {
+			callback, ok := args[0].(Function)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "expected a callback function but got %v", args[0].Type())
+			}
+			exit_hooks = append(exit_hooks, callback)
+			return NewNil()
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	// Process arguments and standard streams
+
+	// args: []string
+	// The arguments given to the program after its path
+	arguments := make([]Value, len(script_args))
+	for i, arg := range script_args {
+		arguments[i] = NewString(arg)
+	}
+	module.exports["args"] = NewSlice(arguments, PrimitiveType{StringType})
+
+	// stdin: stream
+	// The standard input, read with read_line, read_all, lines and each_line
+	module.exports["stdin"] = stdin_stream
+
+	// stdout, stderr: stream
+	// The standard output and error, written with write, print, println and printf
+	module.exports["stdout"] = stdout_stream
+	module.exports["stderr"] = stderr_stream
+
 	return *module
 }
 
-func init_json_module() Module {
+func init_path_module() Module {
 	module := NewModule()
 
-	module.exports["parse"] = NewNativeFunction(
+	// join(elements: ...string): string
+	// Purpose: Joins path elements with the separator of the platform and cleans the result
+	module.exports["join"] = NewVariadicNativeFunction(
 		func(args ...Value) Value {
-			jsonStr := args[0].(String).Value()
-			var result interface{}
-			if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-				panic(fmt.Sprintf("JSON parse error: %v", err))
+			elements := make([]string, len(args))
+			for i, arg := range args {
+				elements[i] = arg.(String).Value()
 			}
+			return NewString(filepath.Join(elements...))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
 
-			return convert_to_value(result)
+	// dir(path: string): string
+	// Purpose: Returns all but the last element of a path
+	module.exports["dir"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(filepath.Dir(args[0].(String).Value()))
 		},
 		[]Type{PrimitiveType{StringType}},
-		PrimitiveType{AnyType},
+		PrimitiveType{StringType},
 	)
 
-	module.exports["stringify"] = NewNativeFunction(
+	// base(path: string): string
+	// Purpose: Returns the last element of a path
+	module.exports["base"] = NewNativeFunction(
 		func(args ...Value) Value {
-			data := args[0].(Map)
-			nativeMap := make(map[string]interface{})
+			return NewString(filepath.Base(args[0].(String).Value()))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
 
-			for _, entry := range *data.entries {
-				key := entry.key.(String).Value()
-				nativeMap[key] = convert_to_native(entry.value)
+	// ext(path: string): string
+	// Purpose: Returns the extension of the last element including its dot, empty when it has none
+	module.exports["ext"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(filepath.Ext(args[0].(String).Value()))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// clean(path: string): string
+	// Purpose: Returns the shortest equivalent path, resolving . and .. elements and repeated separators
+	module.exports["clean"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(filepath.Clean(args[0].(String).Value()))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// rel(base: string, target: string): string
+	// Purpose: Returns the target path relative to base
+	module.exports["rel"] = NewNativeFunction(
+		func(args ...Value) Value {
+			rel, err := filepath.Rel(args[0].(String).Value(), args[1].(String).Value())
+			if err != nil {
+				throw_error(ErrorCodeInvalid, "%v", err)
 			}
+			return NewString(rel)
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// split(path: string): []string
+	// Purpose: Splits a path into its directory, ending with a separator, and its file name
+	module.exports["split"] = NewNativeFunction(
+		func(args ...Value) Value {
+			dir, file := filepath.Split(args[0].(String).Value())
+			return NewSlice([]Value{NewString(dir), NewString(file)}, PrimitiveType{StringType})
+		},
+		[]Type{PrimitiveType{StringType}},
+		NewSliceType(PrimitiveType{StringType}),
+	)
+
+	// is_abs(path: string): bool
+	// Purpose: Reports whether a path is absolute
+	module.exports["is_abs"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewBoolean(filepath.IsAbs(args[0].(String).Value()))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{BooleanType},
+	)
 
-			jsonBytes, err := json.Marshal(nativeMap)
+	// abs(path: string): string
+	// Purpose: Returns the absolute path for a path relative to the working directory
+	module.exports["abs"] = NewNativeFunction(
+		func(args ...Value) Value {
+			abs, err := filepath.Abs(args[0].(String).Value())
 			if err != nil {
-				panic(fmt.Sprintf("JSON stringify error: %v", err))
+				throw_native_error("", err)
 			}
-			return NewString(string(jsonBytes))
+			return NewString(abs)
 		},
-		[]Type{NewMapType(PrimitiveType{AnyType}, PrimitiveType{AnyType})},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// home_dir(): string
+	// Purpose: Returns the home directory of the current user
+	module.exports["home_dir"] = NewNativeFunction(
+		func(args ...Value) Value {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				throw_error(ErrorCodeNotFound, "%v", err)
+			}
+			return NewString(home)
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	// temp_dir(): string
+	// Purpose: Returns the directory for temporary files
+	module.exports["temp_dir"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(os.TempDir())
+		},
+		[]Type{},
 		PrimitiveType{StringType},
 	)
 
+	// separator: string
+	// The separator of path elements on this platform
+	module.exports["separator"] = NewString(string(filepath.Separator))
+
 	return *module
 }
 
-func init_xml_module() Module {
+func init_json_module() Module {
 	module := NewModule()
 
-	// parse(xmlString: string): map
-	// Purpose: Parses an XML string into a map
-	module.exports["parse"] = NewNativeFunction(
+	// parse(json: string, options?: map[string -> any]): any
+	// Purpose: Parses JSON keeping the order of object keys, "big_numbers" -> "string" keeps integers too large for a number as strings
+	module.exports["parse"] = NewVariadicNativeFunction(
 		func(args ...Value) Value {
-			xmlStr := args[0].(String).Value()
-			var result map[string]interface{}
-			if err := xml.Unmarshal([]byte(xmlStr), &result); err != nil {
-				panic(fmt.Sprintf("XML parse error: %v", err))
+			var options Value
+			if len(args) > 1 {
+				options = args[1]
 			}
+			return json_parse(args[0].(String).Value(), json_parse_options(options))
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
 
-			entries := make([]MapEntry, 0)
-			for k, v := range result {
-				entries = append(entries, MapEntry{
-					key:   NewString(k),
-					value: convert_to_value(v),
-				})
+	// stringify(value: any, options?: map[string -> any]): string
+	// Purpose: Encodes any value as JSON, options may set "indent", "sort_keys" and "escape_html"
+	stringify := NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			var options Value
+			if len(args) > 1 {
+				options = args[1]
 			}
-			return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{AnyType})
+			return NewString(json_stringify(args[0], json_stringify_options(options)))
 		},
-		[]Type{PrimitiveType{StringType}},
-		NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{StringType},
 	)
+	module.exports["stringify"] = stringify
+
+	// encode(value: any, options?: map[string -> any]): string
+	// Purpose: Same as stringify, struct fields are named and omitted according to their @json tags
+	module.exports["encode"] = stringify
 
-	// stringify(data: map): string
-	// Purpose: Converts a map into an XML string
-	module.exports["stringify"] = NewNativeFunction(
+	// parse_lines(input: string | stream | file, callback?: fn(value, line)): []any | nil
+	// Purpose: Parses JSON Lines as they are read, passing each value and its line to the callback or returning them all
+	module.exports["parse_lines"] = NewVariadicNativeFunction(
 		func(args ...Value) Value {
-			data := args[0].(Map)
-			nativeMap := make(map[string]interface{})
+			var callback Function
+			if len(args) > 1 {
+				var ok bool
+				if callback, ok = args[1].(Function); !ok {
+					panic(fmt.Sprintf("parse_lines callback must be a function but got %v", args[1].Type()))
+				}
+			}
 
-			for _, entry := range *data.entries {
-				key := entry.key.(String).Value()
-				nativeMap[key] = convert_to_native(entry.value)
+			values := json_parse_lines(json_lines_source(args[0]), callback)
+			if callback != nil {
+				return NewNil()
 			}
+			return NewSlice(values, PrimitiveType{AnyType})
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
 
-			xmlBytes, err := helpers.MapToXml("", nativeMap)
-			if err != nil {
-				panic(err)
+	// stringify_lines(values: []any): string
+	// Purpose: Encodes each value as JSON on its own line
+	module.exports["stringify_lines"] = NewNativeFunction(
+		func(args ...Value) Value {
+			lines := ""
+			for _, value := range *args[0].(Slice).elements {
+				lines += json_stringify(value, json_stringify_options(nil)) + "\n"
 			}
+			return NewString(lines)
+		},
+		[]Type{NewSliceType(PrimitiveType{AnyType})},
+		PrimitiveType{StringType},
+	)
+
+	// decode(json: string, type: any): any
+	// Purpose: Decodes JSON into a value of the given struct or type, mismatches report their path in the document
+	module.exports["decode"] = NewNativeFunction(
+		func(args ...Value) Value {
+			var result interface{}
+			if err := json.Unmarshal([]byte(args[0].(String).Value()), &result); err != nil {
+				// Parsing again reports the position of the syntax error
+				json_parse(args[0].(String).Value(), false)
+				throw_native_error("JSON decode error", err)
+			}
+
+			return json_decode(result, args[1].Type(), "$")
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
+
+	return *module
+}
+
+func init_xml_module() Module {
+	module := NewModule()
+
+	// parse(xmlString: string): xml
+	// Purpose: Parses an XML document and returns its root element
+	module.exports["parse"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return xml_parse(args[0].(String).Value())
+		},
+		[]Type{PrimitiveType{StringType}},
+		XmlType{},
+	)
 
-			return NewString(string(xmlBytes))
+	// stringify(data: xml | map, options?: map): string
+	// Purpose: Serializes an element, or a map following the to_map convention, into an XML string
+	module.exports["stringify"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			var options Value
+			if len(args) > 1 {
+				options = args[1]
+			}
+			return NewString(xml_stringify(xml_from_value(args[0]), xml_stringify_options(options)))
 		},
-		[]Type{NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType})},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}},
 		PrimitiveType{StringType},
 	)
 
+	// element(name: string, attrs?: map, children?: []any): xml
+	// Purpose: Creates an element with the given attributes and children, which are elements or text
+	module.exports["element"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			element := NewXmlElement(args[0].(String).Value())
+			if len(args) > 1 {
+				attrs, ok := args[1].(Map)
+				if !ok {
+					throw_error(ErrorCodeInvalid, "xml attributes must be a map but got %v", args[1].Type())
+				}
+				for _, entry := range *attrs.entries {
+					element.SetAttr(xml_map_key(entry.key), format_value(entry.value, false))
+				}
+			}
+			if len(args) > 2 {
+				for _, child := range elements_of(args[2]) {
+					element.Append(xml_node(child))
+				}
+			}
+			return element
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		XmlType{},
+	)
+
+	// from_map(data: map): xml
+	// Purpose: Builds an element from a map following the to_map convention
+	module.exports["from_map"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return xml_from_map(args[0])
+		},
+		[]Type{PrimitiveType{AnyType}},
+		XmlType{},
+	)
+
+	// to_map(element: xml): map
+	// Purpose: Converts an element into a map, see the XML library documentation for the convention
+	module.exports["to_map"] = NewNativeFunction(
+		func(args ...Value) Value {
+			element, ok := args[0].(*XmlElement)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "expected an xml element but got %v", args[0].Type())
+			}
+			return xml_to_map(element)
+		},
+		[]Type{XmlType{}},
+		NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
+	)
+
 	return *module
 }
 
@@ -634,25 +1077,37 @@ func convert_to_value(native interface{}) Value {
 		return NewNumber(v)
 	case int:
 		return NewNumber(float64(v))
+	case int64:
+		return NewNumber(float64(v))
+	case uint64:
+		return NewNumber(float64(v))
 	case bool:
 		return NewBoolean(v)
 	case nil:
 		return NewNil()
+	case time.Time:
+		return NewString(v.Format(time.RFC3339Nano))
 	case map[string]interface{}:
 		entries := make([]MapEntry, 0)
-		for k, val := range v {
+		for _, k := range sorted_keys(v) {
 			entries = append(entries, MapEntry{
 				key:   NewString(k),
-				value: convert_to_value(val),
+				value: convert_to_value(v[k]),
 			})
 		}
 		return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{AnyType})
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			converted[fmt.Sprint(k)] = val
+		}
+		return convert_to_value(converted)
 	case map[string]string:
 		entries := make([]MapEntry, 0)
-		for k, val := range v {
+		for _, k := range sorted_keys(v) {
 			entries = append(entries, MapEntry{
 				key:   NewString(k),
-				value: NewString(val),
+				value: NewString(v[k]),
 			})
 		}
 		return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{StringType})
@@ -662,11 +1117,28 @@ func convert_to_value(native interface{}) Value {
 			elements[i] = convert_to_value(val)
 		}
 		return NewSlice(elements, PrimitiveType{AnyType})
+	case []map[string]interface{}:
+		elements := make([]Value, len(v))
+		for i, val := range v {
+			elements[i] = convert_to_value(val)
+		}
+		return NewSlice(elements, NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}))
+	case fmt.Stringer:
+		return NewString(v.String())
 	default:
 		panic(fmt.Sprintf("unsupported type: %T", v))
 	}
 }
 
+func sorted_keys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func convert_to_native(value Value) interface{} {
 	switch v := value.(type) {
 	case Reference:
@@ -677,8 +1149,15 @@ func convert_to_native(value Value) interface{} {
 		return v.Value()
 	case Boolean:
 		return v.Value()
-	case *Nil:
+	case Nil:
 		return nil
+	case *Pointer:
+		if v.target == nil {
+			return nil
+		}
+		return convert_to_native(v.target.Load())
+	case StructInstantiation:
+		return struct_to_native(v)
 	case Map:
 		result := make(map[string]interface{})
 		for _, entry := range *v.entries {
@@ -703,13 +1182,62 @@ func convert_to_native(value Value) interface{} {
 	}
 }
 
+// elements_of returns the elements of a slice or array, or the value itself
+// if it is neither
+func elements_of(value Value) []Value {
+	switch value := value.(type) {
+	case Slice:
+		return *value.elements
+	case Array:
+		return value.elements
+	default:
+		return []Value{value}
+	}
+}
+
+// native_integers converts whole numbers in a native value to int64, for
+// encoders that write floats and integers differently
+func native_integers(native interface{}) interface{} {
+	switch v := native.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < max_safe_integer {
+			return int64(v)
+		}
+		return v
+	case map[string]interface{}:
+		for k, val := range v {
+			v[k] = native_integers(val)
+		}
+		return v
+	case []interface{}:
+		for i, val := range v {
+			v[i] = native_integers(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
 func load_native_modules() {
 	standard_modules["math"] = init_math_module()
+	standard_modules["fmt"] = init_fmt_module()
 	standard_modules["random"] = init_random_module()
 	standard_modules["time"] = init_time_module()
 	standard_modules["os"] = init_os_module()
+	standard_modules["path"] = init_path_module()
+	standard_modules["process"] = init_process_module()
 	standard_modules["net"] = init_net_module()
 	standard_modules["json"] = init_json_module()
 	standard_modules["xml"] = init_xml_module()
+	standard_modules["csv"] = init_csv_module()
+	standard_modules["yaml"] = init_yaml_module()
+	standard_modules["toml"] = init_toml_module()
+	standard_modules["encoding"] = init_encoding_module()
+	standard_modules["crypto"] = init_crypto_module()
 	standard_modules["http"] = init_http_module()
+	standard_modules["regex"] = init_regex_module()
+	standard_modules["errors"] = init_errors_module()
+	standard_modules["reflect"] = init_reflect_module()
+	standard_modules["assert"] = init_assert_module()
 }
@@ -6,8 +6,6 @@ import (
 	"io"
 	"net/http"
 	"strings"
-
-	"github.com/table-harmony/HarmonyLang/src/helpers"
 )
 
 // RoutePattern represents a parsed route pattern
@@ -189,16 +187,11 @@ func (res *Response) init_methods() {
 
 	res.Methods["xml"] = NewNativeFunction(
 		func(args ...Value) Value {
-			data := args[0]
 			res.Headers["Content-Type"] = "application/xml"
-			xmlMap, err := helpers.MapToXml("", convert_to_native(data).(map[string]interface{}))
-			if err != nil {
-				panic(err)
-			}
-			res.Body.Write(xmlMap)
+			res.Body.WriteString(xml_stringify(xml_from_value(args[0]), xml_options{declaration: true}))
 			return res
 		},
-		[]Type{NewMapType(PrimitiveType{AnyType}, PrimitiveType{AnyType})},
+		[]Type{PrimitiveType{AnyType}},
 		ResponseType{},
 	)
 
@@ -356,99 +349,29 @@ func init_net_module() Module {
 func init_http_module() Module {
 	module := NewModule()
 
-	sendRequest := func(method, url string, reqMap Map) Value {
-		req := NewRequest()
-		req.Path = url
-		req.Method = method
-
-		for _, entry := range *reqMap.entries {
-			if entry.key.(String).value == "headers" {
-				headers := convert_to_native(entry.value).(map[string]interface{})
-				for k, v := range headers {
-					req.Headers[k] = v.(string)
-				}
-			}
-
-			if entry.key.(String).value == "query" {
-				query := convert_to_native(entry.value).(map[string]interface{})
-				for k, v := range query {
-					req.Query[k] = v.(string)
-				}
-			}
-
-			if entry.key.(String).value == "body" {
-				req.Body = entry.value.(String).Value()
-			}
-		}
-
-		var queryParams string
-		if strings.Contains(url, "?") {
-			queryParams = "&"
-		} else {
-			queryParams = "?"
-		}
-
-		for key, value := range req.Query {
-			queryParams += fmt.Sprintf("%s=%s&", key, value)
-		}
-		fullURL := url + strings.TrimRight(queryParams, "&")
-
-		httpReq, err := http.NewRequest(req.Method, fullURL, strings.NewReader(req.Body))
-		if err != nil {
-			panic(err)
-		}
-		for key, value := range req.Headers {
-			httpReq.Header.Set(key, value)
-		}
-
-		client := &http.Client{}
-		res, err := client.Do(httpReq)
-		if err != nil {
-			panic(err)
-		}
-		defer res.Body.Close()
-
-		body, err := io.ReadAll(res.Body)
-		if err != nil {
-			panic(err)
-		}
+	// get, head, post, put, patch, delete(url: string, options?: map[string -> any]): map[string -> any]
+	// request(method: string, url: string, options?: map[string -> any]): map[string -> any]
+	// Purpose: Send a request without keeping cookies between requests
+	for name, function := range http_request_functions(default_http_options, nil) {
+		module.exports[name] = function
+	}
 
-		headerEntries := []MapEntry{}
-		for key, values := range res.Header {
-			if len(values) > 0 {
-				headerEntries = append(headerEntries, MapEntry{NewString(key), NewString(values[0])})
+	// client(options?: map[string -> any]): client
+	// Purpose: Creates a client whose options apply to each of its requests and that keeps cookies
+	module.exports["client"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			if len(args) > 1 {
+				throw_error(ErrorCodeInvalid, "client expects at most one options map but got %d arguments", len(args))
 			}
-		}
-		headersMap := NewMap(headerEntries, PrimitiveType{StringType}, PrimitiveType{StringType})
-
-		entries := []MapEntry{
-			{NewString("statusCode"), NewNumber(float64(res.StatusCode))},
-			{NewString("body"), NewString(string(body))},
-			{NewString("headers"), headersMap},
-		}
-
-		for key, values := range res.Header {
-			if len(values) > 0 {
-				entries = append(entries, MapEntry{NewString(key), NewString(values[0])})
+			var options Value
+			if len(args) > 0 {
+				options = args[0]
 			}
-		}
-
-		result := NewMap(entries, PrimitiveType{StringType}, PrimitiveType{AnyType})
-		return result
-	}
-
-	methods := []string{"get", "post", "put", "patch", "delete"}
-	for _, method := range methods {
-		module.exports[method] = NewNativeFunction(
-			func(args ...Value) Value {
-				url := args[0].(String).Value()
-				reqMap := args[1].(Map)
-				return sendRequest(strings.ToUpper(method), url, reqMap)
-			},
-			[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
-			NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
-		)
-	}
+			return NewClient(options)
+		},
+		[]Type{PrimitiveType{AnyType}},
+		ClientType{},
+	)
 
 	return *module
 }
@@ -0,0 +1,169 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/table-harmony/HarmonyLang/src/lexer"
+)
+
+// operator_methods maps the binary operators a struct can overload to the
+// name of the method implementing them
+var operator_methods = map[lexer.TokenKind]string{
+	lexer.PLUS:           "op_add",
+	lexer.DASH:           "op_sub",
+	lexer.STAR:           "op_mul",
+	lexer.SLASH:          "op_div",
+	lexer.PERCENT:        "op_mod",
+	lexer.EQUALS:         "op_eq",
+	lexer.LESS:           "op_lt",
+	lexer.LESS_EQUALS:    "op_le",
+	lexer.GREATER:        "op_gt",
+	lexer.GREATER_EQUALS: "op_ge",
+}
+
+// operator_fallback describes how a comparison can be derived from another
+// operator method when a struct does not define it directly
+type operator_fallback struct {
+	method  string
+	swapped bool
+	negated bool
+}
+
+var operator_fallbacks = map[lexer.TokenKind][]operator_fallback{
+	lexer.NOT_EQUALS:     {{"op_eq", false, true}},
+	lexer.LESS:           {{"op_gt", true, false}},
+	lexer.GREATER:        {{"op_lt", true, false}},
+	lexer.LESS_EQUALS:    {{"op_lt", true, true}, {"op_gt", false, true}},
+	lexer.GREATER_EQUALS: {{"op_lt", false, true}, {"op_gt", true, true}},
+}
+
+// Method returns the method with the given name bound to the instance, looking
+// through embedded structs as well
+func (s StructInstantiation) Method(name string, ref Reference) (BoundMethod, bool) {
+	holder, holderRef, attr, found := s.Promote(name, ref)
+	if !found || attr.isStatic {
+		return BoundMethod{}, false
+	}
+
+	fnRef, ok := attr.Reference.(*FunctionReference)
+	if !ok {
+		return BoundMethod{}, false
+	}
+	fn, ok := fnRef.value.(*FunctionValue)
+	if !ok {
+		return BoundMethod{}, false
+	}
+
+	return bind_method(fn, holder, holderRef), true
+}
+
+// evaluate_operator_overload dispatches a binary operator to the operator
+// method of a struct operand. The left operand's method is used, comparisons
+// fall back to the right operand or to a related method. It reports false if
+// no operand overloads the operator.
+func evaluate_operator_overload(operator lexer.TokenKind, left, right Value) (Value, bool) {
+	leftInst, leftOk := left.(StructInstantiation)
+	rightInst, rightOk := right.(StructInstantiation)
+	if !leftOk && !rightOk {
+		return nil, false
+	}
+
+	if name, exists := operator_methods[operator]; exists && leftOk {
+		if method, ok := leftInst.Method(name, nil); ok {
+			_, derived := operator_fallbacks[operator]
+			comparison := derived || operator == lexer.EQUALS
+			return call_operator_method(method, name, right, comparison), true
+		}
+	}
+
+	for _, fallback := range operator_fallbacks[operator] {
+		receiver, receiverOk, argument := leftInst, leftOk, right
+		if fallback.swapped {
+			receiver, receiverOk, argument = rightInst, rightOk, left
+		}
+		if !receiverOk {
+			continue
+		}
+
+		method, ok := receiver.Method(fallback.method, nil)
+		if !ok {
+			continue
+		}
+
+		result := call_operator_method(method, fallback.method, argument, true).(Boolean)
+		if fallback.negated {
+			return NewBoolean(!result.Value()), true
+		}
+		return result, true
+	}
+
+	return nil, false
+}
+
+func call_operator_method(method BoundMethod, name string, argument Value, comparison bool) Value {
+	result, err := method.Call(argument)
+	if err != nil {
+		panic(err)
+	}
+
+	if comparison {
+		if _, ok := result.(Boolean); !ok {
+			panic(fmt.Sprintf("operator method '%s' must return a boolean, got %v", name, result.Type()))
+		}
+	}
+
+	return result
+}
+
+// struct_equals compares two struct instances without an op_eq method. They
+// are equal if they were created from the same struct and all their fields
+// are equal.
+func struct_equals(left, right StructInstantiation) bool {
+	if left.constructor.identifier != right.constructor.identifier || !left.constructor._type.Equals(right.constructor._type) {
+		return false
+	}
+	if len(left.storage) != len(right.storage) {
+		return false
+	}
+
+	for name, leftRef := range left.storage {
+		rightRef, exists := right.storage[name]
+		if !exists {
+			return false
+		}
+		if !values_equal(leftRef.Load(), rightRef.Load()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// values_equal reports whether two values are equal using the same rules as
+// the == operator
+func values_equal(left, right Value) bool {
+	if result, ok := evaluate_operator_overload(lexer.EQUALS, left, right); ok {
+		return result.(Boolean).Value()
+	}
+	return evaluate_equals(left, right).(Boolean).Value()
+}
+
+// to_string returns the result of the instance's to_string method, if it
+// defines one
+func (s StructInstantiation) to_string() (string, bool) {
+	method, ok := s.Method("to_string", nil)
+	if !ok {
+		return "", false
+	}
+
+	result, err := method.Call()
+	if err != nil {
+		panic(err)
+	}
+
+	str, ok := result.(String)
+	if !ok {
+		panic(fmt.Sprintf("to_string must return a string, got %v", result.Type()))
+	}
+	return str.Value(), true
+}
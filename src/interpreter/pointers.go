@@ -22,6 +22,11 @@ func (p PointerType) Equals(other Type) bool {
 		return primitive.kind == NilType
 	}
 
+	// Types built by the constructor are pointers, compare them by value
+	if ptr, ok := other.(*PointerType); ok && ptr != nil {
+		other = *ptr
+	}
+
 	otherPtr, ok := other.(PointerType)
 	if !ok {
 		return false
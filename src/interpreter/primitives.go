@@ -30,6 +30,8 @@ func (p PrimitiveType) DefaultValue() Value {
 		return NewString("")
 	case BooleanType:
 		return NewBoolean(false)
+	case ErrorType:
+		return NewNil()
 	case NilType:
 		return NewNil()
 	case AnyType:
@@ -46,6 +48,8 @@ func (p PrimitiveType) String() string {
 		return "string"
 	case BooleanType:
 		return "boolean"
+	case ErrorType:
+		return "error"
 	case NilType:
 		return "nil"
 	case AnyType:
@@ -73,6 +77,9 @@ type String struct {
 type Boolean struct{ value bool }
 type Error struct {
 	value   string
+	code    string
+	cause   Value
+	fields  Map
 	methods map[string]Function
 }
 type Nil struct{}
@@ -207,11 +214,40 @@ func (Nil) String() string { return "nil" }
 func NewNil() Value        { return Nil{} }
 
 // Error implements Value interface
-func (e Error) Type() Type     { return PrimitiveType{ErrorType} }
-func (e Error) Clone() Value   { return NewError(e.value) }
-func (e Error) String() string { return fmt.Sprintf("Error: %s", e.value) }
+func (e *Error) Type() Type   { return PrimitiveType{ErrorType} }
+func (e *Error) Clone() Value { return e }
+func (e *Error) String() string {
+	return fmt.Sprintf("Error: %s", e.Message())
+}
+
+// Message returns the message of the error followed by the messages of the
+// errors it wraps
+func (e *Error) Message() string {
+	if e.cause == nil {
+		return e.value
+	}
+	if cause, ok := e.cause.(*Error); ok {
+		return e.value + ": " + cause.Message()
+	}
+	return e.value + ": " + e.cause.String()
+}
+func (e *Error) Code() string { return e.code }
+func (e *Error) Cause() Value { return e.cause }
+
 func NewError(value string) Value {
-	err := &Error{value, map[string]Function{}}
+	return NewStructuredError(value, "", nil, nil)
+}
+
+// NewStructuredError creates an error with an optional code, cause and map of
+// fields describing it
+func NewStructuredError(value string, code string, cause Value, fields []MapEntry) *Error {
+	err := &Error{
+		value:   value,
+		code:    code,
+		cause:   cause,
+		fields:  NewMap(fields, PrimitiveType{StringType}, PrimitiveType{AnyType}),
+		methods: map[string]Function{},
+	}
 	err.init_methods()
 
 	return err
@@ -219,6 +255,21 @@ func NewError(value string) Value {
 
 func (e *Error) init_methods() {
 	e.methods["message"] = NewNativeFunction(func(args ...Value) Value {
-		return NewString(e.value)
+		return NewString(e.Message())
 	}, []Type{}, PrimitiveType{StringType})
+
+	e.methods["code"] = NewNativeFunction(func(args ...Value) Value {
+		return NewString(e.code)
+	}, []Type{}, PrimitiveType{StringType})
+
+	e.methods["cause"] = NewNativeFunction(func(args ...Value) Value {
+		if e.cause == nil {
+			return NewNil()
+		}
+		return e.cause
+	}, []Type{}, PrimitiveType{AnyType})
+
+	e.methods["fields"] = NewNativeFunction(func(args ...Value) Value {
+		return e.fields
+	}, []Type{}, NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}))
 }
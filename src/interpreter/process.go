@@ -0,0 +1,440 @@
+package interpreter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProcessType represents the type of a program started with process.spawn
+type ProcessType struct{}
+
+// ProcessType implements the Type interface
+func (ProcessType) String() string { return "process" }
+func (p ProcessType) Equals(other Type) bool {
+	_, ok := other.(ProcessType)
+	return ok
+}
+func (p ProcessType) DefaultValue() Value { return NewNil() }
+
+// Process is a running program. Its standard streams are piped to the script
+// unless they were given as input or inherited. Processes are shared by
+// reference.
+type Process struct {
+	command string
+	cmd     *exec.Cmd
+	context context.Context
+	cancel  context.CancelFunc
+	start   time.Time
+	stdin   io.WriteCloser
+	streams map[string]*Stream
+	// outputs are the read ends of the stdout and stderr pipes
+	outputs map[string]*os.File
+	result  Value
+	methods map[string]Function
+}
+
+// process_options are the options of process.run and process.spawn
+type process_options struct {
+	env     []string
+	dir     string
+	timeout time.Duration
+	stdin   *string
+	check   bool
+	inherit bool
+}
+
+func parse_process_options(options Value) process_options {
+	opts := process_options{}
+	if options == nil {
+		return opts
+	}
+
+	values, ok := options.(Map)
+	if !ok {
+		throw_error(ErrorCodeInvalid, "process options must be a map but got %v", options.Type())
+	}
+
+	env := os.Environ()
+	for _, entry := range *values.entries {
+		switch key := entry.key.String(); key {
+		case "env":
+			variables, ok := entry.value.(Map)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "process option 'env' must be a map but got %v", entry.value.Type())
+			}
+			for _, variable := range *variables.entries {
+				env = append(env, variable.key.String()+"="+variable.value.String())
+			}
+			opts.env = env
+		case "clear_env":
+			if process_bool_option(key, entry.value) {
+				env = env[len(os.Environ()):]
+				opts.env = env
+			}
+		case "dir":
+			dir, ok := entry.value.(String)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "process option 'dir' must be a string but got %v", entry.value.Type())
+			}
+			opts.dir = dir.Value()
+		case "timeout":
+			timeout, ok := entry.value.(Number)
+			if !ok || timeout.Value() <= 0 {
+				throw_error(ErrorCodeInvalid, "process option 'timeout' must be a positive number of milliseconds but got %v", entry.value)
+			}
+			opts.timeout = time.Duration(timeout.Value() * float64(time.Millisecond))
+		case "stdin":
+			input, ok := entry.value.(String)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "process option 'stdin' must be a string but got %v", entry.value.Type())
+			}
+			text := input.Value()
+			opts.stdin = &text
+		case "check":
+			opts.check = process_bool_option(key, entry.value)
+		case "inherit":
+			opts.inherit = process_bool_option(key, entry.value)
+		default:
+			throw_error(ErrorCodeInvalid, "unknown process option: %s", key)
+		}
+	}
+	return opts
+}
+
+func process_bool_option(key string, value Value) bool {
+	flag, ok := value.(Boolean)
+	if !ok {
+		throw_error(ErrorCodeInvalid, "process option '%s' must be a bool but got %v", key, value.Type())
+	}
+	return flag.Value()
+}
+
+// process_command builds the command for the arguments of process.run and
+// process.spawn: the program, its optional arguments and optional options
+func process_command(args []Value) (*exec.Cmd, context.Context, context.CancelFunc, process_options) {
+	if len(args) > 3 {
+		throw_error(ErrorCodeInvalid, "expected a command, its arguments and options but got %d arguments", len(args))
+	}
+
+	arguments := make([]string, 0)
+	if len(args) > 1 {
+		list, ok := args[1].(Slice)
+		if !ok {
+			throw_error(ErrorCodeInvalid, "expected the arguments as a slice but got %v", args[1].Type())
+		}
+		for _, argument := range *list.elements {
+			text, ok := argument.(String)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "expected string arguments but got %v", argument.Type())
+			}
+			arguments = append(arguments, text.Value())
+		}
+	}
+
+	var options Value
+	if len(args) > 2 {
+		options = args[2]
+	}
+	opts := parse_process_options(options)
+
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if opts.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+	}
+
+	cmd := exec.CommandContext(ctx, args[0].(String).Value(), arguments...)
+	cmd.Env = opts.env
+	cmd.Dir = opts.dir
+	// Output left open by children of a killed process does not hold up waiting
+	cmd.WaitDelay = time.Second
+	if opts.inherit {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	}
+	if opts.stdin != nil {
+		cmd.Stdin = strings.NewReader(*opts.stdin)
+	}
+	return cmd, ctx, cancel, opts
+}
+
+// process_result describes how a process ended, throwing when it could not
+// run, timed out, or failed and the check option is set
+func process_result(cmd *exec.Cmd, ctx context.Context, err error, opts process_options, duration time.Duration, output []MapEntry) Value {
+	command := strings.Join(cmd.Args, " ")
+	entry := func(key string, value Value) MapEntry {
+		return MapEntry{key: NewString(key), value: value}
+	}
+
+	code := 0
+	var exitErr *exec.ExitError
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		panic(NewThrowError(NewStructuredError(
+			fmt.Sprintf("%s timed out after %v", command, opts.timeout),
+			ErrorCodeTimeout, nil,
+			append([]MapEntry{entry("command", NewString(command))}, output...),
+		)))
+	case errors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+	case err != nil:
+		throw_native_error("", err)
+	}
+
+	if opts.check && code != 0 {
+		panic(NewThrowError(NewStructuredError(
+			fmt.Sprintf("%s exited with code %d", command, code),
+			ErrorCodeProcess, nil,
+			append([]MapEntry{entry("command", NewString(command)), entry("code", NewNumber(float64(code)))}, output...),
+		)))
+	}
+
+	entries := []MapEntry{
+		entry("command", NewString(command)),
+		entry("code", NewNumber(float64(code))),
+		entry("success", NewBoolean(code == 0)),
+	}
+	entries = append(entries, output...)
+	entries = append(entries, entry("duration", NewNumber(float64(duration.Milliseconds()))))
+	return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{AnyType})
+}
+
+// run_process runs a command to completion, capturing its output unless it is
+// inherited
+func run_process(args []Value) Value {
+	cmd, ctx, cancel, opts := process_command(args)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	if !opts.inherit {
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	output := []MapEntry{
+		{key: NewString("stdout"), value: NewString(stdout.String())},
+		{key: NewString("stderr"), value: NewString(stderr.String())},
+	}
+	return process_result(cmd, ctx, err, opts, time.Since(start), output)
+}
+
+// SpawnProcess starts a command without waiting for it to finish
+func SpawnProcess(args []Value) *Process {
+	cmd, ctx, cancel, opts := process_command(args)
+	process := &Process{
+		command: strings.Join(cmd.Args, " "),
+		cmd:     cmd,
+		context: ctx,
+		cancel:  cancel,
+		streams: make(map[string]*Stream),
+		outputs: make(map[string]*os.File),
+		methods: make(map[string]Function),
+	}
+
+	// The output pipes are made here rather than by exec, whose pipes are
+	// closed by Wait before the script may have read them
+	writers := make([]*os.File, 0, 2)
+	closeAll := func() {
+		for _, writer := range writers {
+			writer.Close()
+		}
+		for _, reader := range process.outputs {
+			reader.Close()
+		}
+	}
+	pipe := func(name string, target *io.Writer) {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			cancel()
+			closeAll()
+			throw_native_error(process.command, err)
+		}
+		*target = writer
+		writers = append(writers, writer)
+		process.outputs[name] = reader
+		process.streams[name] = NewReadStream(name, reader)
+	}
+	if cmd.Stdin == nil {
+		writer, err := cmd.StdinPipe()
+		if err != nil {
+			cancel()
+			throw_native_error(process.command, err)
+		}
+		process.stdin = writer
+		process.streams["stdin"] = NewWriteStream("stdin", writer)
+	}
+	if cmd.Stdout == nil {
+		pipe("stdout", &cmd.Stdout)
+	}
+	if cmd.Stderr == nil {
+		pipe("stderr", &cmd.Stderr)
+	}
+
+	process.start = time.Now()
+	err := cmd.Start()
+	// The process holds its own copies of the write ends
+	for _, writer := range writers {
+		writer.Close()
+	}
+	if err != nil {
+		cancel()
+		closeAll()
+		throw_native_error("", err)
+	}
+
+	process.init_methods(opts)
+	return process
+}
+
+// Process implements the Value interface
+func (*Process) Type() Type       { return ProcessType{} }
+func (p *Process) Clone() Value   { return p }
+func (p *Process) String() string { return fmt.Sprintf("process(%s)", p.command) }
+
+// close_stdin closes the input of the process so it reads the end of it
+func (p *Process) close_stdin() {
+	if p.stdin == nil {
+		return
+	}
+	if err := p.stdin.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		throw_native_error(p.command, err)
+	}
+}
+
+// wait waits for the process to end while reading what the script left of
+// its output, so that a process writing more than a pipe holds does not block
+// on it. The output stays readable from the streams afterwards.
+func (p *Process) wait() error {
+	var group sync.WaitGroup
+	for name := range p.outputs {
+		stream := p.streams[name]
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			rest, _ := io.ReadAll(stream.reader)
+			stream.reader = bufio.NewReader(bytes.NewReader(rest))
+		}()
+	}
+	drained := make(chan struct{})
+	go func() {
+		group.Wait()
+		close(drained)
+	}()
+
+	err := p.cmd.Wait()
+	select {
+	case <-drained:
+	case <-time.After(p.cmd.WaitDelay):
+		// Children of the process still hold the pipes open
+		for _, output := range p.outputs {
+			output.Close()
+		}
+		<-drained
+	}
+	for _, output := range p.outputs {
+		output.Close()
+	}
+	return err
+}
+
+func (p *Process) init_methods(opts process_options) {
+	for _, name := range []string{"stdin", "stdout", "stderr"} {
+		// stdin(), stdout(), stderr(): stream
+		// Purpose: Returns the pipe to or from the process
+		p.methods[name] = NewNativeFunction(
+			func(args ...Value) Value {
+				stream, ok := p.streams[name]
+				if !ok {
+					throw_error(ErrorCodeInvalid, "the %s of %s is not piped", name, p.command)
+				}
+				return stream
+			},
+			[]Type{},
+			StreamType{},
+		)
+	}
+
+	// pid(): number
+	// Purpose: Returns the process id
+	p.methods["pid"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewNumber(float64(p.cmd.Process.Pid))
+		},
+		[]Type{},
+		PrimitiveType{NumberType},
+	)
+
+	// close_stdin(): nil
+	// Purpose: Closes the input pipe so the process reads the end of its input
+	p.methods["close_stdin"] = NewNativeFunction(
+		func(args ...Value) Value {
+			p.close_stdin()
+			return NewNil()
+		},
+		[]Type{},
+		PrimitiveType{NilType},
+	)
+
+	// wait(): map[string -> any]
+	// Purpose: Closes the input and waits for the process to end, returning its command, code, success and duration, output not read yet stays in the streams
+	p.methods["wait"] = NewNativeFunction(
+		func(args ...Value) Value {
+			if p.result == nil {
+				p.close_stdin()
+				err := p.wait()
+				p.result = process_result(p.cmd, p.context, err, opts, time.Since(p.start), nil)
+				p.cancel()
+			}
+			return p.result
+		},
+		[]Type{},
+		NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
+	)
+
+	// kill(): nil
+	// Purpose: Kills the process, wait then reports a code of -1
+	p.methods["kill"] = NewNativeFunction(
+		func(args ...Value) Value {
+			if err := p.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				throw_native_error(p.command, err)
+			}
+			return NewNil()
+		},
+		[]Type{},
+		PrimitiveType{NilType},
+	)
+}
+
+func init_process_module() Module {
+	module := NewModule()
+
+	// run(command: string, args?: []string, options?: map[string -> any]): map[string -> any]
+	// Purpose: Runs a program to completion, returning its command, code, success, stdout, stderr and duration in milliseconds
+	module.exports["run"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			return run_process(args)
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
+	)
+
+	// spawn(command: string, args?: []string, options?: map[string -> any]): process
+	// Purpose: Starts a program and returns it without waiting, its input and output are streams
+	module.exports["spawn"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			return SpawnProcess(args)
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		ProcessType{},
+	)
+
+	return *module
+}
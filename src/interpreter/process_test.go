@@ -0,0 +1,53 @@
+package interpreter
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestProcessWaitReadsLargeOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+
+	expect_output(t, `
+import process from "process"
+import fmt from "fmt"
+const child = process.spawn("sh", []string{"-c", "seq 1 200000; echo done >&2",}, map{"timeout" -> 10000,})
+const result = child.wait()
+const lines = child.stdout().lines()
+fmt.println(result["code"], lines.len(), lines[199999], child.stderr().read_line())
+`, "0 200000 200000 done")
+}
+
+func TestProcessOutputIsReadableAfterWait(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+
+	expect_output(t, `
+import process from "process"
+import fmt from "fmt"
+const child = process.spawn("sh", []string{"-c", "echo first; echo second",})
+fmt.println(child.stdout().read_line())
+child.wait()
+fmt.println(child.stdout().read_line(), child.stdout().read_line())
+`, "first\nsecond nil")
+}
+
+func TestProcessOptionErrorsAreInvalid(t *testing.T) {
+	expect_output(t, `
+import process from "process"
+import fmt from "fmt"
+for _, options in []any{map{"timeout" -> -1,}, map{"check" -> "yes",}, map{"shell" -> true,}, 1,} {
+  try {
+    process.run("true", []string{}, options)
+  } catch (e: error) {
+    fmt.println(e.code(), e.message())
+  }
+}
+`, `invalid process option 'timeout' must be a positive number of milliseconds but got -1
+invalid process option 'check' must be a bool but got string
+invalid unknown process option: shell
+invalid process options must be a map but got number`)
+}
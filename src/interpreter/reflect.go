@@ -0,0 +1,346 @@
+package interpreter
+
+import (
+	"sort"
+)
+
+// reflect_kind returns the name of the category a type belongs to
+func reflect_kind(_type Type) string {
+	switch _type := _type.(type) {
+	case PrimitiveType:
+		return _type.String()
+	case ValueType:
+		return reflect_kind(_type._type)
+	case ArrayType:
+		return "array"
+	case SliceType, *SliceType:
+		return "slice"
+	case MapType, *MapType:
+		return "map"
+	case PointerType, *PointerType:
+		return "pointer"
+	case FunctionType, NativeFunctionType:
+		return "function"
+	case StructType:
+		return "struct"
+	case ModuleType:
+		return "module"
+	case RegexType:
+		return "regex"
+	case XmlType:
+		return "xml"
+	case StreamType:
+		return "stream"
+	case FileType:
+		return "file"
+	case ProcessType:
+		return "process"
+	case ClientType:
+		return "client"
+	case RequestType:
+		return "request"
+	case ResponseType:
+		return "response"
+	case ServerType:
+		return "server"
+	default:
+		return "unknown"
+	}
+}
+
+// reflect_target resolves the struct declaration or instance a reflect
+// function operates on. Pointers to instances are followed and the reference
+// holding the instance is returned so that it can be modified.
+func reflect_target(value Value) (*Struct, *StructInstantiation, Reference) {
+	switch value := value.(type) {
+	case *Struct:
+		return value, nil, nil
+	case StructInstantiation:
+		return &value.constructor, &value, nil
+	case *Pointer:
+		if value.target == nil {
+			break
+		}
+		if instance, ok := value.target.Load().(StructInstantiation); ok {
+			return &instance.constructor, &instance, value.target
+		}
+	}
+
+	throw_error(ErrorCodeInvalid, "expected a struct, an instance or a pointer to an instance but got %v", value.Type())
+	return nil, nil, nil
+}
+
+// reflect_fields describes the fields of a struct sorted by name
+func reflect_fields(_type StructType) []Value {
+	names := make([]string, 0, len(_type.storage))
+	for name, attr := range _type.storage {
+		if _, ok := attr.Reference.(*FunctionReference); !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	embedded := make(map[string]bool)
+	for _, name := range _type.embedded {
+		embedded[name] = true
+	}
+
+	fields := make([]Value, 0, len(names))
+	for _, name := range names {
+		attr := _type.storage[name]
+		isConstant := false
+		if ref, ok := attr.Reference.(*VariableReference); ok {
+			isConstant = ref.isConstant
+		}
+
+		fields = append(fields, reflect_descriptor([]MapEntry{
+			{NewString("name"), NewString(name)},
+			{NewString("type"), NewValueType(attr.Reference.Type())},
+			{NewString("static"), NewBoolean(attr.isStatic)},
+			{NewString("constant"), NewBoolean(isConstant)},
+			{NewString("required"), NewBoolean(attr.isRequired)},
+			{NewString("embedded"), NewBoolean(embedded[name])},
+			{NewString("tags"), reflect_tags(attr.tags)},
+		}))
+	}
+
+	return fields
+}
+
+// reflect_methods describes the static methods of a struct and its instance
+// methods, including promoted ones, sorted by name
+func reflect_methods(_type StructType) []Value {
+	methods := _type.Methods()
+	for name, attr := range _type.storage {
+		if _, ok := attr.Reference.(*FunctionReference); ok && attr.isStatic {
+			methods[name] = attr
+		}
+	}
+
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	descriptors := make([]Value, 0, len(names))
+	for _, name := range names {
+		attr := methods[name]
+		isPointer := false
+		if fn, ok := attr.Reference.Load().(*FunctionValue); ok && fn.receiver != nil {
+			isPointer = fn.receiver.isPointer
+		}
+
+		descriptors = append(descriptors, reflect_descriptor([]MapEntry{
+			{NewString("name"), NewString(name)},
+			{NewString("type"), NewValueType(attr.Reference.Type())},
+			{NewString("static"), NewBoolean(attr.isStatic)},
+			{NewString("pointer"), NewBoolean(isPointer)},
+		}))
+	}
+
+	return descriptors
+}
+
+// reflect_params describes the parameters of a function type. Native
+// functions do not name their parameters.
+func reflect_params(_type Type) []Value {
+	if value, ok := _type.(ValueType); ok {
+		_type = value._type
+	}
+
+	params := make([]Value, 0)
+	switch _type := _type.(type) {
+	case FunctionType:
+		for _, param := range _type.parameters {
+			params = append(params, reflect_descriptor([]MapEntry{
+				{NewString("name"), NewString(param.identifier)},
+				{NewString("type"), NewValueType(param.valueType)},
+			}))
+		}
+	case NativeFunctionType:
+		for _, param := range _type.paramTypes {
+			params = append(params, reflect_descriptor([]MapEntry{
+				{NewString("name"), NewString("")},
+				{NewString("type"), NewValueType(param)},
+			}))
+		}
+	default:
+		throw_error(ErrorCodeInvalid, "expected a function but got %v", _type)
+	}
+
+	return params
+}
+
+// reflect_tags converts the tags of a field to a map from tag name to its
+// arguments
+func reflect_tags(tags map[string][]string) Value {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]MapEntry, len(names))
+	for i, name := range names {
+		arguments := make([]Value, len(tags[name]))
+		for j, argument := range tags[name] {
+			arguments[j] = NewString(argument)
+		}
+		entries[i] = MapEntry{NewString(name), NewSlice(arguments, PrimitiveType{StringType})}
+	}
+	return NewMap(entries, PrimitiveType{StringType}, NewSliceType(PrimitiveType{StringType}))
+}
+
+func reflect_descriptor(entries []MapEntry) Value {
+	return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{AnyType})
+}
+
+func init_reflect_module() Module {
+	module := NewModule()
+	descriptors := NewSliceType(NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}))
+
+	// type_of(value: any): type
+	// Purpose: Returns the type of a value, same as the typeof operator
+	module.exports["type_of"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewValueType(args[0].Type())
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
+
+	// kind(value: any): string
+	// Purpose: Returns the category of a value or type, such as "number", "slice", "struct" or "function"
+	module.exports["kind"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(reflect_kind(args[0].Type()))
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{StringType},
+	)
+
+	// fields(target: struct | instance | pointer): []map[string -> any]
+	// Purpose: Describes the fields of a struct with their name, type and modifiers
+	module.exports["fields"] = NewNativeFunction(
+		func(args ...Value) Value {
+			declaration, _, _ := reflect_target(args[0])
+			return NewSlice(reflect_fields(declaration._type), descriptors.elementType)
+		},
+		[]Type{PrimitiveType{AnyType}},
+		descriptors,
+	)
+
+	// methods(target: struct | instance | pointer): []map[string -> any]
+	// Purpose: Describes the methods of a struct with their name, type and receiver
+	module.exports["methods"] = NewNativeFunction(
+		func(args ...Value) Value {
+			declaration, _, _ := reflect_target(args[0])
+			return NewSlice(reflect_methods(declaration._type), descriptors.elementType)
+		},
+		[]Type{PrimitiveType{AnyType}},
+		descriptors,
+	)
+
+	// get_field(target: struct | instance | pointer, name: string): any
+	// Purpose: Returns a field of an instance, including promoted ones, or a static field of a struct
+	module.exports["get_field"] = NewNativeFunction(
+		func(args ...Value) Value {
+			declaration, instance, ref := reflect_target(args[0])
+			name := args[1].(String).Value()
+
+			if instance == nil {
+				attr, exists := declaration._type.storage[name]
+				if !exists || !attr.isStatic {
+					throw_error(ErrorCodeNotFound, "struct %s has no static member '%s'", declaration.identifier, name)
+				}
+				return attr.Reference.Load()
+			}
+
+			holder, holderRef, attr, exists := instance.Promote(name, ref)
+			if !exists || attr.isStatic {
+				throw_error(ErrorCodeNotFound, "struct %s has no field '%s'", declaration.identifier, name)
+			}
+			if field, exists := holder.storage[name]; exists {
+				return field.Load()
+			}
+			if fn, ok := attr.Reference.Load().(*FunctionValue); ok {
+				return bind_method(fn, holder, holderRef)
+			}
+			return attr.Reference.Load()
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}},
+		PrimitiveType{AnyType},
+	)
+
+	// set_field(target: struct | pointer, name: string, value: any): nil
+	// Purpose: Sets a field of the instance a pointer points at, or a static field of a struct
+	module.exports["set_field"] = NewNativeFunction(
+		func(args ...Value) Value {
+			declaration, instance, ref := reflect_target(args[0])
+			name := args[1].(String).Value()
+
+			var field Reference
+			if instance == nil {
+				attr, exists := declaration._type.storage[name]
+				if !exists || !attr.isStatic {
+					throw_error(ErrorCodeNotFound, "struct %s has no static member '%s'", declaration.identifier, name)
+				}
+				field = attr.Reference
+			} else {
+				if ref == nil {
+					throw_error(ErrorCodeInvalid, "cannot set field '%s' on a copy of %s, pass a pointer to the instance", name, declaration.identifier)
+				}
+
+				holder, _, attr, exists := instance.Promote(name, ref)
+				field, exists = holder.storage[name]
+				if !exists || attr.isStatic {
+					throw_error(ErrorCodeNotFound, "struct %s has no field '%s'", declaration.identifier, name)
+				}
+			}
+
+			if err := field.Store(args[2]); err != nil {
+				panic(err)
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	// call(fn: any, args: []any): any
+	// Purpose: Calls a function with the arguments in the slice
+	module.exports["call"] = NewNativeFunction(
+		func(args ...Value) Value {
+			fn, ok := args[0].(Function)
+			if !ok {
+				throw_error(ErrorCodeInvalid, "expected a function but got %v", args[0].Type())
+			}
+
+			arguments := make([]Value, 0)
+			for _, arg := range *args[1].(Slice).elements {
+				arguments = append(arguments, arg.Clone())
+			}
+
+			result, err := fn.Call(arguments...)
+			if err != nil {
+				panic(err)
+			}
+			return result
+		},
+		[]Type{PrimitiveType{AnyType}, NewSliceType(PrimitiveType{AnyType})},
+		PrimitiveType{AnyType},
+	)
+
+	// params(fn: any): []map[string -> any]
+	// Purpose: Describes the parameters of a function or function type with their name and type
+	module.exports["params"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewSlice(reflect_params(args[0].Type()), descriptors.elementType)
+		},
+		[]Type{PrimitiveType{AnyType}},
+		descriptors,
+	)
+
+	return *module
+}
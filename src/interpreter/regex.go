@@ -0,0 +1,354 @@
+package interpreter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RegexType represents the type of a compiled regular expression
+type RegexType struct{}
+
+// RegexType implements the Type interface
+func (RegexType) String() string { return "regex" }
+func (r RegexType) Equals(other Type) bool {
+	_, ok := other.(RegexType)
+	return ok
+}
+func (r RegexType) DefaultValue() Value { return NewNil() }
+
+// Regex represents a compiled regular expression with methods
+type Regex struct {
+	pattern *regexp.Regexp
+	methods map[string]Function
+}
+
+func NewRegex(pattern *regexp.Regexp) Regex {
+	regex := Regex{
+		pattern: pattern,
+		methods: make(map[string]Function),
+	}
+	regex.init_methods()
+	return regex
+}
+
+// Regex implements the Value interface
+func (Regex) Type() Type                { return RegexType{} }
+func (r Regex) Clone() Value            { return r }
+func (r Regex) String() string          { return fmt.Sprintf("regex(%s)", r.pattern.String()) }
+func (r Regex) Pattern() *regexp.Regexp { return r.pattern }
+
+func (r Regex) init_methods() {
+	r.methods["pattern"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(r.pattern.String())
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	r.methods["match"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_match(r.pattern, args[0].(String).Value())
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{BooleanType},
+	)
+
+	r.methods["find"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_find(r.pattern, args[0].(String).Value())
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{AnyType},
+	)
+
+	r.methods["find_all"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_find_all(r.pattern, args[0].(String).Value())
+		},
+		[]Type{PrimitiveType{StringType}},
+		NewSliceType(PrimitiveType{StringType}),
+	)
+
+	r.methods["groups"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_groups(r.pattern, args[0].(String).Value())
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{AnyType},
+	)
+
+	r.methods["captures"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_captures(r.pattern, args[0].(String).Value())
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{AnyType},
+	)
+
+	r.methods["captures_all"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_captures_all(r.pattern, args[0].(String).Value())
+		},
+		[]Type{PrimitiveType{StringType}},
+		NewSliceType(PrimitiveType{AnyType}),
+	)
+
+	r.methods["replace"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_replace(r.pattern, args[0].(String).Value(), args[1])
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{StringType},
+	)
+
+	r.methods["split"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_split(r.pattern, args[0].(String).Value())
+		},
+		[]Type{PrimitiveType{StringType}},
+		NewSliceType(PrimitiveType{StringType}),
+	)
+}
+
+// expect_regex accepts either a compiled regex or a pattern string
+func expect_regex(value Value) *regexp.Regexp {
+	switch value := value.(type) {
+	case Regex:
+		return value.pattern
+	case String:
+		pattern, err := regexp.Compile(value.Value())
+		if err != nil {
+			throw_native_error("Regex compile error", err)
+		}
+		return pattern
+	default:
+		panic(fmt.Sprintf("expected a regex or a pattern string but got %v", value.Type()))
+	}
+}
+
+func regex_match(pattern *regexp.Regexp, input string) Value {
+	return NewBoolean(pattern.MatchString(input))
+}
+
+func regex_find(pattern *regexp.Regexp, input string) Value {
+	location := pattern.FindStringIndex(input)
+	if location == nil {
+		return NewNil()
+	}
+	return NewString(input[location[0]:location[1]])
+}
+
+func regex_find_all(pattern *regexp.Regexp, input string) Value {
+	matches := pattern.FindAllString(input, -1)
+	elements := make([]Value, len(matches))
+	for i, match := range matches {
+		elements[i] = NewString(match)
+	}
+	return NewSlice(elements, PrimitiveType{StringType})
+}
+
+func regex_groups(pattern *regexp.Regexp, input string) Value {
+	submatches := pattern.FindStringSubmatch(input)
+	if submatches == nil {
+		return NewNil()
+	}
+
+	elements := make([]Value, len(submatches))
+	for i, submatch := range submatches {
+		elements[i] = NewString(submatch)
+	}
+	return NewSlice(elements, PrimitiveType{StringType})
+}
+
+// regex_named_groups builds a map of the named groups of a single match
+func regex_named_groups(pattern *regexp.Regexp, submatches []string) Map {
+	entries := make([]MapEntry, 0)
+	for i, name := range pattern.SubexpNames() {
+		if name == "" || i >= len(submatches) {
+			continue
+		}
+		entries = append(entries, MapEntry{
+			key:   NewString(name),
+			value: NewString(submatches[i]),
+		})
+	}
+	return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{StringType})
+}
+
+func regex_captures(pattern *regexp.Regexp, input string) Value {
+	submatches := pattern.FindStringSubmatch(input)
+	if submatches == nil {
+		return NewNil()
+	}
+	return regex_named_groups(pattern, submatches)
+}
+
+func regex_captures_all(pattern *regexp.Regexp, input string) Value {
+	matches := pattern.FindAllStringSubmatch(input, -1)
+	elements := make([]Value, len(matches))
+	for i, submatches := range matches {
+		elements[i] = regex_named_groups(pattern, submatches)
+	}
+	return NewSlice(elements, PrimitiveType{AnyType})
+}
+
+// regex_replace replaces every match of the pattern. A string replacement may
+// reference capture groups with $1 or ${name}, a function replacement is called
+// with the matched text and, if it accepts a second parameter, the groups.
+func regex_replace(pattern *regexp.Regexp, input string, replacement Value) Value {
+	if str, ok := replacement.(String); ok {
+		return NewString(pattern.ReplaceAllString(input, str.Value()))
+	}
+
+	callback, ok := replacement.(Function)
+	if !ok {
+		panic(fmt.Sprintf("replacement must be a string or a function but got %v", replacement.Type()))
+	}
+	withGroups := function_arity(callback) >= 2
+
+	var result strings.Builder
+	last := 0
+	for _, location := range pattern.FindAllStringSubmatchIndex(input, -1) {
+		result.WriteString(input[last:location[0]])
+
+		args := []Value{NewString(input[location[0]:location[1]])}
+		if withGroups {
+			groups := make([]Value, 0, len(location)/2)
+			for i := 0; i < len(location); i += 2 {
+				if location[i] < 0 {
+					groups = append(groups, NewString(""))
+					continue
+				}
+				groups = append(groups, NewString(input[location[i]:location[i+1]]))
+			}
+			args = append(args, NewSlice(groups, PrimitiveType{StringType}))
+		}
+
+		value, err := callback.Call(args...)
+		if err != nil {
+			panic(err)
+		}
+		result.WriteString(value.String())
+		last = location[1]
+	}
+	result.WriteString(input[last:])
+
+	return NewString(result.String())
+}
+
+func regex_split(pattern *regexp.Regexp, input string) Value {
+	parts := pattern.Split(input, -1)
+	elements := make([]Value, len(parts))
+	for i, part := range parts {
+		elements[i] = NewString(part)
+	}
+	return NewSlice(elements, PrimitiveType{StringType})
+}
+
+func init_regex_module() Module {
+	module := NewModule()
+
+	// compile(pattern: string): regex
+	// Purpose: Compiles a pattern into a reusable regex value
+	module.exports["compile"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewRegex(expect_regex(args[0]))
+		},
+		[]Type{PrimitiveType{StringType}},
+		RegexType{},
+	)
+
+	// escape(text: string): string
+	// Purpose: Escapes all regex metacharacters in the text
+	module.exports["escape"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(regexp.QuoteMeta(args[0].(String).Value()))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{StringType},
+	)
+
+	// match(pattern: regex | string, input: string): bool
+	// Purpose: Reports whether the input contains a match of the pattern
+	module.exports["match"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_match(expect_regex(args[0]), args[1].(String).Value())
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}},
+		PrimitiveType{BooleanType},
+	)
+
+	// find(pattern: regex | string, input: string): string | nil
+	// Purpose: Returns the leftmost match of the pattern or nil
+	module.exports["find"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_find(expect_regex(args[0]), args[1].(String).Value())
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}},
+		PrimitiveType{AnyType},
+	)
+
+	// find_all(pattern: regex | string, input: string): []string
+	// Purpose: Returns every successive match of the pattern
+	module.exports["find_all"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_find_all(expect_regex(args[0]), args[1].(String).Value())
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}},
+		NewSliceType(PrimitiveType{StringType}),
+	)
+
+	// groups(pattern: regex | string, input: string): []string | nil
+	// Purpose: Returns the leftmost match followed by its capture groups
+	module.exports["groups"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_groups(expect_regex(args[0]), args[1].(String).Value())
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}},
+		PrimitiveType{AnyType},
+	)
+
+	// captures(pattern: regex | string, input: string): map[string -> string] | nil
+	// Purpose: Returns the named groups of the leftmost match
+	module.exports["captures"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_captures(expect_regex(args[0]), args[1].(String).Value())
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}},
+		PrimitiveType{AnyType},
+	)
+
+	// captures_all(pattern: regex | string, input: string): []map[string -> string]
+	// Purpose: Returns the named groups of every match
+	module.exports["captures_all"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_captures_all(expect_regex(args[0]), args[1].(String).Value())
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}},
+		NewSliceType(PrimitiveType{AnyType}),
+	)
+
+	// replace(pattern: regex | string, input: string, replacement: string | fn): string
+	// Purpose: Replaces every match, expanding $1 / ${name} or calling the replacement function
+	module.exports["replace"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_replace(expect_regex(args[0]), args[1].(String).Value(), args[2])
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{StringType},
+	)
+
+	// split(pattern: regex | string, input: string): []string
+	// Purpose: Splits the input around every match of the pattern
+	module.exports["split"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return regex_split(expect_regex(args[0]), args[1].(String).Value())
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{StringType}},
+		NewSliceType(PrimitiveType{StringType}),
+	)
+
+	return *module
+}
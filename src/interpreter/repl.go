@@ -3,6 +3,7 @@ package interpreter
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -25,6 +26,10 @@ func StartREPL() {
 	for {
 		fmt.Print(">> ")
 		input, err := repl.reader.ReadString('\n')
+		if err == io.EOF {
+			fmt.Println()
+			break
+		}
 		if err != nil {
 			fmt.Println("Error reading input:", err)
 			continue
@@ -48,9 +53,10 @@ func StartREPL() {
 
 func create_repl() REPL {
 	create_lookups()
+	load_native_modules()
 
 	return REPL{
-		scope:  NewScope(nil),
+		scope:  NewRootScope(),
 		reader: bufio.NewReader(os.Stdin),
 	}
 }
@@ -84,20 +90,24 @@ func (repl *REPL) evaluate_statement(statement ast.Statement) Value {
 }
 
 func print_value(value Value) {
+	fmt.Println(repl_format(value))
+}
+
+// repl_format renders a value the way the REPL echoes it, strings quoted
+func repl_format(value Value) string {
 	switch v := value.(type) {
 	case Number:
 		if v.Value() == float64(int(v.Value())) {
-			fmt.Printf("%d\n", int(v.Value()))
-		} else {
-			fmt.Printf("%g\n", v.Value())
+			return fmt.Sprintf("%d", int(v.Value()))
 		}
+		return fmt.Sprintf("%g", v.Value())
 	case String:
-		fmt.Printf("%q\n", v.Value())
+		return fmt.Sprintf("%q", v.Value())
 	case Boolean:
-		fmt.Printf("%t\n", v.Value())
+		return fmt.Sprintf("%t", v.Value())
 	case Reference:
-		print_value(v.Load())
+		return repl_format(v.Load())
 	default:
-		fmt.Printf("%v\n", value)
+		return fmt.Sprintf("%v", value)
 	}
 }
@@ -0,0 +1,183 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/table-harmony/HarmonyLang/src/ast"
+	"github.com/table-harmony/HarmonyLang/src/lexer"
+	"github.com/table-harmony/HarmonyLang/src/parser"
+)
+
+// UncaughtError is a value thrown by a program that no catch clause handled,
+// or a runtime failure that stopped it
+type UncaughtError struct {
+	value Value
+}
+
+func (e UncaughtError) Error() string {
+	return "uncaught " + format_value(e.value, false)
+}
+
+// uncaught converts a recovered panic into an UncaughtError
+func uncaught(r any) error {
+	return UncaughtError{exception_value(r)}
+}
+
+// script_args holds the arguments given to the program after its path
+var script_args = make([]string, 0)
+
+// SetArgs sets the arguments passed to the program
+func SetArgs(args []string) {
+	script_args = args
+}
+
+// Parse turns source code into a program, reporting syntax errors instead of
+// panicking
+func Parse(source string) (program []ast.Statement, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("syntax error: %s", strings.TrimSpace(fmt.Sprint(r)))
+		}
+	}()
+
+	return parser.Parse(lexer.Tokenize(source)), nil
+}
+
+// Run runs a program read from path, empty when it did not come from a file.
+// An exception that escapes the program is returned as an UncaughtError.
+func Run(program []ast.Statement, path string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = uncaught(r)
+		}
+		err = exit(err)
+	}()
+
+	InterpretFile(program, path)
+	return nil
+}
+
+// Evaluate runs a program and returns the value of its last statement when
+// it is an expression, formatted the way the REPL prints it
+func Evaluate(program []ast.Statement, path string) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = uncaught(r)
+		}
+		err = exit(err)
+	}()
+
+	if len(program) == 0 {
+		return "", nil
+	}
+
+	last, ok := program[len(program)-1].(ast.ExpressionStatement)
+	if !ok {
+		InterpretFile(program, path)
+		return "", nil
+	}
+
+	scope := InterpretFile(program[:len(program)-1], path)
+	value := evaluate_expression(last.Expression, scope)
+	if value == nil {
+		return "", nil
+	}
+	if _, ok := value.(Nil); ok {
+		return "", nil
+	}
+	return repl_format(value), nil
+}
+
+// exit runs the exit hooks once the program ended with the given error and
+// closes the files left open, returning that error or else the first error of
+// a hook
+func exit(err error) error {
+	code := 0
+	if err != nil {
+		code = 1
+	}
+
+	hookErr := run_exit_hooks(code)
+	close_files()
+	if err == nil {
+		return hookErr
+	}
+	return err
+}
+
+// TestResult is the outcome of one test function
+type TestResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// test_prefix starts the names of the functions run by RunTests
+const test_prefix = "test_"
+
+// RunTests runs a test file: its top level statements first, then each
+// function whose name starts with test_ in the order they are declared. A
+// test fails when it throws. The returned error is set when the file itself
+// fails before any test runs, or when its deferred calls fail after them.
+func RunTests(program []ast.Statement, path string, report func(TestResult)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = uncaught(r)
+		}
+		err = exit(err)
+	}()
+
+	// The deferred calls of the top level wait for the tests, which may use
+	// what it set up
+	file := start_program(path)
+	scope := NewRootScope()
+	scope.file = file
+	defer scope.RunDeferred()
+
+	evaluate_program(program, scope)
+	for _, name := range test_names(program) {
+		start := time.Now()
+		err := run_test(scope, name)
+		report(TestResult{Name: name, Err: err, Duration: time.Since(start)})
+	}
+	return nil
+}
+
+func test_names(program []ast.Statement) []string {
+	names := make([]string, 0)
+	for _, statement := range program {
+		if export, ok := statement.(ast.ExportStatement); ok {
+			statement = export.Declaration
+		}
+
+		function, ok := statement.(ast.FunctionDeclarationStatment)
+		if ok && function.Receiver == nil && strings.HasPrefix(function.Identifier, test_prefix) {
+			names = append(names, function.Identifier)
+		}
+	}
+	return names
+}
+
+func run_test(scope *Scope, name string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = uncaught(r)
+		}
+	}()
+
+	ref, err := scope.Resolve(name)
+	if err != nil {
+		return err
+	}
+	function, ok := ref.Load().(Function)
+	if !ok {
+		return fmt.Errorf("%s is not a function", name)
+	}
+
+	if _, err := function.Call(); err != nil {
+		return uncaught(err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package interpreter
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const deferred_tests_source = `
+let closed = false
+let runs = 0
+defer {
+  closed = true
+  if runs != RUNS {
+    throw error("closed after " + string(runs) + " tests")
+  }
+}
+
+fn test_first() {
+  runs++
+  if closed {
+    throw error("closed before test_first")
+  }
+}
+
+fn test_second() {
+  runs++
+  if closed {
+    throw error("closed before test_second")
+  }
+}
+`
+
+// run_tests runs a test file and returns the names of the tests that passed
+func run_tests(t *testing.T, source string) ([]string, error) {
+	t.Helper()
+
+	program, err := Parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passed := make([]string, 0)
+	err = RunTests(program, filepath.Join(t.TempDir(), "main_test.harmony"), func(result TestResult) {
+		if result.Err != nil {
+			t.Errorf("%s failed: %v", result.Name, result.Err)
+			return
+		}
+		passed = append(passed, result.Name)
+	})
+	return passed, err
+}
+
+func TestTopLevelDeferRunsAfterTests(t *testing.T) {
+	passed, err := run_tests(t, strings.ReplaceAll(deferred_tests_source, "RUNS", "2"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(passed, " ") != "test_first test_second" {
+		t.Errorf("unexpected passed tests %v", passed)
+	}
+}
+
+func TestTopLevelDeferErrorIsReported(t *testing.T) {
+	_, err := run_tests(t, strings.ReplaceAll(deferred_tests_source, "RUNS", "3"))
+	if err == nil || !strings.Contains(err.Error(), "closed after 2 tests") {
+		t.Errorf("expected the deferred error, got %v", err)
+	}
+}
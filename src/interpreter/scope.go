@@ -10,23 +10,42 @@ type Scope struct {
 	parent       *Scope
 	storage      map[string]Reference
 	declarations map[string]Declaration
+	deferred     *[]func()
+	file         string
+	exports      map[string]bool
 }
 
 func NewScope(parent *Scope) *Scope {
-	return &Scope{
+	scope := &Scope{
 		parent:       parent,
 		storage:      make(map[string]Reference),
 		declarations: make(map[string]Declaration),
 	}
+
+	// Nested scopes share the deferred calls of their enclosing function
+	if parent != nil {
+		scope.deferred = parent.deferred
+	}
+
+	return scope
+}
+
+// NewFunctionScope creates the scope of a function body which owns the calls
+// deferred inside of it
+func NewFunctionScope(parent *Scope) *Scope {
+	scope := NewScope(parent)
+	scope.deferred = &[]func(){}
+	return scope
 }
 
 func NewRootScope() *Scope {
-	scope := NewScope(nil)
+	scope := NewFunctionScope(nil)
 
 	// Declare native printing functions
 	scope.Declare(NewFunctionReference("print", native_print))
 	scope.Declare(NewFunctionReference("println", native_println))
 	scope.Declare(NewFunctionReference("printf", native_printf))
+	scope.Declare(NewFunctionReference("sprintf", native_sprintf))
 
 	// Declare native type conversion functions
 	scope.Declare(NewFunctionReference("string", native_string))
@@ -37,6 +56,23 @@ func NewRootScope() *Scope {
 	return scope
 }
 
+// File returns the path of the file the scope belongs to, empty when the
+// code did not come from a file
+func (scope *Scope) File() string {
+	for scope.parent != nil {
+		scope = scope.parent
+	}
+	return scope.file
+}
+
+// Export marks a top level name as part of the module's public surface
+func (scope *Scope) Export(identifier string) {
+	if scope.exports == nil {
+		scope.exports = make(map[string]bool)
+	}
+	scope.exports[identifier] = true
+}
+
 func (scope *Scope) Declare(ref Reference) error {
 	var identifier string
 
@@ -47,6 +83,8 @@ func (scope *Scope) Declare(ref Reference) error {
 		identifier = ref.identifier
 	case *Struct:
 		identifier = ref.identifier
+	case *ImportReference:
+		identifier = ref.identifier
 	}
 
 	if _, exists := scope.storage[identifier]; exists {
@@ -57,6 +95,29 @@ func (scope *Scope) Declare(ref Reference) error {
 	return nil
 }
 
+// Defer schedules a call to run when the enclosing function exits
+func (scope *Scope) Defer(call func()) error {
+	if scope.deferred == nil {
+		return fmt.Errorf("defer outside of a function")
+	}
+	*scope.deferred = append(*scope.deferred, call)
+	return nil
+}
+
+// RunDeferred runs the deferred calls in last in first out order. Every call
+// runs even if an earlier one panics, the last panic is the one propagated.
+func (scope *Scope) RunDeferred() {
+	if scope.deferred == nil {
+		return
+	}
+
+	calls := *scope.deferred
+	*scope.deferred = nil
+	for _, call := range calls {
+		defer call()
+	}
+}
+
 func (scope *Scope) Resolve(identifier string) (Reference, error) {
 	if ref, exists := scope.storage[identifier]; exists {
 		return ref, nil
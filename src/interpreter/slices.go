@@ -23,6 +23,11 @@ func (s SliceType) Equals(other Type) bool {
 		return primitive.kind == NilType
 	}
 
+	// Types built by the constructor are pointers, compare them by value
+	if ptr, ok := other.(*SliceType); ok && ptr != nil {
+		other = *ptr
+	}
+
 	otherSlice, ok := other.(SliceType)
 	if !ok {
 		return false
@@ -30,11 +35,12 @@ func (s SliceType) Equals(other Type) bool {
 	return s.elementType.Equals(otherSlice.elementType)
 }
 
+// Slice is a growable sequence of elements. Its length and capacity are those
+// of the shared elements, so every copy of the value, like the one its
+// methods are bound to, sees the same elements after an append.
 type Slice struct {
 	elements *[]Value
 	_type    SliceType
-	length   int
-	capacity int
 	methods  map[string]NativeFunctionValue
 }
 
@@ -50,8 +56,6 @@ func NewSlice(elements []Value, elementType Type) Slice {
 	slice := Slice{
 		elements: &sliceElements,
 		_type:    *NewSliceType(elementType),
-		length:   0,
-		capacity: capacity,
 		methods:  make(map[string]NativeFunctionValue),
 	}
 
@@ -62,7 +66,6 @@ func NewSlice(elements []Value, elementType Type) Slice {
 		}
 
 		*(slice.elements) = append(*(slice.elements), element)
-		slice.length++
 	}
 
 	slice.init_methods()
@@ -96,17 +99,11 @@ func (s *Slice) Append(value Value) {
 			value.Type().String(), s._type.elementType.String()))
 	}
 
-	if s.length == s.capacity {
-		newCap := s.capacity * 2
-		newElements := make([]Value, s.length, newCap)
-		copy(newElements, *s.elements)
-		s.elements = &newElements
-		s.capacity = newCap
-	}
-
 	*(s.elements) = append(*s.elements, value)
-	s.length++
 }
+
+// Len returns the number of elements
+func (s *Slice) Len() int { return len(*s.elements) }
 func (s *Slice) Get(property Value) Value {
 	position, ok := property.(Number)
 	if !ok {
@@ -143,8 +140,8 @@ func (s *Slice) Set(property Value, value Value) {
 		index = len(*s.elements) + index
 	}
 
-	if index >= s.length {
-		panic(fmt.Sprintf("Index out of range [%d] with length %d", index, s.length))
+	if index >= s.Len() {
+		panic(fmt.Sprintf("Index out of range [%d] with length %d", index, s.Len()))
 	}
 	if !s._type.elementType.Equals(value.Type()) {
 		panic(fmt.Sprintf("Cannot set %s in slice of %s",
@@ -171,23 +168,21 @@ func (s *Slice) Slice(start Value, end Value) Slice {
 	endIndex := int(endValue.value)
 
 	if startIndex < 0 {
-		startIndex = s.length + startIndex
+		startIndex = s.Len() + startIndex
 	}
 	if endIndex < 0 {
-		endIndex = s.length + endIndex
+		endIndex = s.Len() + endIndex
 	}
 
-	if startIndex < 0 || endIndex > s.length || startIndex > endIndex {
+	if startIndex < 0 || endIndex > s.Len() || startIndex > endIndex {
 		panic(fmt.Sprintf("Invalid slice indices [%d:%d] with length %d",
-			startIndex, endIndex, s.length))
+			startIndex, endIndex, s.Len()))
 	}
 
 	elements := (*s.elements)[startIndex:endIndex]
 	newSlice := Slice{
 		elements: &elements,
 		_type:    s._type,
-		length:   endIndex - startIndex,
-		capacity: s.capacity - startIndex,
 		methods:  make(map[string]NativeFunctionValue),
 	}
 	newSlice.init_methods()
@@ -196,13 +191,13 @@ func (s *Slice) Slice(start Value, end Value) Slice {
 
 func (s *Slice) init_methods() {
 	s.methods["len"] = *NewNativeFunction(
-		func(args ...Value) Value { return NewNumber(float64(s.length)) },
+		func(args ...Value) Value { return NewNumber(float64(s.Len())) },
 		[]Type{},
 		PrimitiveType{NumberType},
 	)
 
 	s.methods["cap"] = *NewNativeFunction(
-		func(args ...Value) Value { return NewNumber(float64(s.capacity)) },
+		func(args ...Value) Value { return NewNumber(float64(cap(*s.elements))) },
 		[]Type{},
 		PrimitiveType{NumberType},
 	)
@@ -0,0 +1,26 @@
+package interpreter
+
+import "testing"
+
+func TestSliceAppendIsSeenByLoops(t *testing.T) {
+	expect_output(t, `
+import fmt from "fmt"
+let items = []number{}
+for let i = 0; i < 20; i++ { items.append(i) }
+let count = 0
+for _, item in items { count = count + item }
+fmt.println(items.len(), count)
+`, "20 190")
+}
+
+func TestSliceCopiesKeepTheirOwnElements(t *testing.T) {
+	expect_output(t, `
+import fmt from "fmt"
+let items = []number{1, 2}
+let copy = items
+copy.append(3)
+let count = 0
+for _, item in items { count = count + 1 }
+fmt.println(items.len(), count, copy.len(), items)
+`, "2 2 3 []number[1, 2]")
+}
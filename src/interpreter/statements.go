@@ -2,12 +2,10 @@ package interpreter
 
 import (
 	"fmt"
-	"os"
 	"reflect"
 
 	"github.com/table-harmony/HarmonyLang/src/ast"
 	"github.com/table-harmony/HarmonyLang/src/lexer"
-	"github.com/table-harmony/HarmonyLang/src/parser"
 )
 
 func (interpreter *interpreter) evalute_current_statement(scope *Scope) {
@@ -157,6 +155,9 @@ func evaluate_iterator_for_statement(statement ast.Statement, scope *Scope) {
 
 	loopScope := NewScope(scope)
 	iteratorValue := evaluate_expression(expectedStatement.Iterator, loopScope)
+	if ref, ok := iteratorValue.(Reference); ok {
+		iteratorValue = ref.Load()
+	}
 
 	var keyType, valueType Type
 	iterations := 0
@@ -168,7 +169,7 @@ func evaluate_iterator_for_statement(statement ast.Statement, scope *Scope) {
 	case Slice:
 		keyType = PrimitiveType{NumberType}
 		valueType = iterator._type.elementType
-		iterations = iterator.length
+		iterations = iterator.Len()
 	case Map:
 		keyType = iterator._type.keyType
 		valueType = iterator._type.valueType
@@ -265,6 +266,39 @@ func evaluate_iterator_for_statement(statement ast.Statement, scope *Scope) {
 	}
 }
 
+func evaluate_defer_statement(statement ast.Statement, scope *Scope) {
+	expectedStatement, err := ast.ExpectStatement[ast.DeferStatement](statement)
+	if err != nil {
+		panic(err)
+	}
+
+	// Like a call statement, the callee and arguments of a deferred call are
+	// evaluated immediately and only the call itself is delayed
+	var call func()
+	if expression, ok := expectedStatement.Value.(ast.CallExpression); ok {
+		function := evaluate_callee(expression.Caller, scope)
+		params := make([]Value, 0)
+		for _, param := range expression.Params {
+			params = append(params, evaluate_expression(param, scope).Clone())
+		}
+
+		call = func() {
+			if _, err := function.Call(params...); err != nil {
+				panic(err)
+			}
+		}
+	} else {
+		call = func() {
+			evaluate_expression(expectedStatement.Value, scope)
+		}
+	}
+
+	err = scope.Defer(call)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func evaluate_function_declaration_statement(statement ast.Statement, scope *Scope) {
 	expectedStatement, err := ast.ExpectStatement[ast.FunctionDeclarationStatment](statement)
 	if err != nil {
@@ -278,6 +312,38 @@ func evaluate_function_declaration_statement(statement ast.Statement, scope *Sco
 		scope,
 	)
 
+	// A function with a receiver is a method added to an existing struct
+	if expectedStatement.Receiver != nil {
+		identifier, receiver := evaluate_receiver(expectedStatement.Receiver)
+		ref, err := scope.Resolve(identifier)
+		if err != nil {
+			panic(fmt.Errorf("cannot declare method '%s' on undefined struct '%s'", expectedStatement.Identifier, identifier))
+		}
+		structRef, ok := ref.Load().(*Struct)
+		if !ok {
+			panic(fmt.Errorf("cannot declare method '%s' on non-struct type '%s'", expectedStatement.Identifier, identifier))
+		}
+		if _, exists := structRef._type.storage[expectedStatement.Identifier]; exists {
+			panic(fmt.Errorf("attribute '%s' already exists", expectedStatement.Identifier))
+		}
+
+		valuePtr.receiver = receiver
+		if expectedStatement.Identifier == "new" {
+			if !receiver.isPointer {
+				panic(fmt.Errorf("constructor of struct '%s' must have a pointer receiver", identifier))
+			}
+			if structRef._type.constructor != nil {
+				panic(fmt.Errorf("struct '%s' already has a constructor", identifier))
+			}
+			structRef._type.constructor = valuePtr
+			return
+		}
+		structRef._type.storage[expectedStatement.Identifier] = StructAttribute{
+			Reference: NewFunctionReference(expectedStatement.Identifier, valuePtr),
+		}
+		return
+	}
+
 	ref := NewFunctionReference(
 		expectedStatement.Identifier,
 		*valuePtr,
@@ -352,11 +418,19 @@ func evaluate_assignment_statement(statement ast.Statement, scope *Scope) {
 				panic(err)
 			}
 		case StructInstantiation:
+			if method, ok := owner.Method("op_set_index", nil); ok {
+				if _, err := method.Call(property, value); err != nil {
+					panic(err)
+				}
+				return
+			}
+
 			propertyName, err := ExpectValue[String](property)
 			if err != nil {
 				panic(fmt.Sprintf("invalid property name: %v", property))
 			}
-			attribute, exists := owner.storage[propertyName.Value()]
+			holder, _, _, _ := owner.Promote(propertyName.Value(), nil)
+			attribute, exists := holder.storage[propertyName.Value()]
 			if !exists {
 				panic(fmt.Sprintf("struct instantiation has no attribute '%s'", propertyName.Value()))
 			}
@@ -397,6 +471,14 @@ func evaluate_throw_statement(statement ast.Statement, scope *Scope) {
 		panic(err)
 	}
 
+	if expectedStatement.Value == nil {
+		ref, err := scope.Resolve(exception_identifier)
+		if err != nil {
+			panic("rethrow outside of a catch clause")
+		}
+		panic(NewThrowError(ref.Load()))
+	}
+
 	value := evaluate_expression(expectedStatement.Value, scope)
 	panic(NewThrowError(value))
 }
@@ -427,49 +509,45 @@ func evaluate_import_statement(statement ast.Statement, scope *Scope) {
 		panic(err)
 	}
 
-	var module Module
+	module := import_module(expectedStatement.Module, scope)
 
-	_, err = os.Stat(expectedStatement.Module)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			panic(err)
+	for key, value := range expectedStatement.NamedImports {
+		member := module.Member(key)
+		if ref, ok := member.(Reference); ok {
+			scope.Declare(NewImportReference(value, ref))
+		} else {
+			scope.Declare(NewVariableReference(value, true, member, member.Type()))
 		}
+	}
 
-		var exists bool
-		module, exists = standard_modules[expectedStatement.Module]
-		if !exists {
-			panic(fmt.Sprintf("module '%s' not found", expectedStatement.Module))
-		}
-	} else {
-		file, err := os.ReadFile(expectedStatement.Module)
-		if err != nil {
-			panic(err)
-		}
-		source := string(file)
-
-		tokens := lexer.Tokenize(source)
-		ast := parser.Parse(tokens)
-		moduleScope := Interpret(ast)
-
-		module = *NewModule()
-		for _, ref := range moduleScope.storage {
-			switch ref := ref.(type) {
-			case *FunctionReference:
-				module.exports[ref.identifier] = ref.Clone()
-			case *VariableReference:
-				module.exports[ref.identifier] = ref.Clone()
-			case *Struct:
-				module.exports[ref.identifier] = ref.Clone()
-			}
-		}
+	if expectedStatement.Alias != "" {
+		scope.Declare(NewVariableReference(expectedStatement.Alias, true, module, PrimitiveType{AnyType}))
 	}
+}
 
-	for key, value := range expectedStatement.NamedImports {
-		scope.Declare(NewVariableReference(value, true, module.exports[key], module.exports[key].Type()))
+func evaluate_export_statement(statement ast.Statement, scope *Scope) {
+	expectedStatement, err := ast.ExpectStatement[ast.ExportStatement](statement)
+	if err != nil {
+		panic(err)
 	}
 
-	if expectedStatement.Alias != "" {
-		scope.Declare(NewVariableReference(expectedStatement.Alias, true, module, PrimitiveType{AnyType}))
+	if scope.parent != nil {
+		panic(fmt.Errorf("export is only allowed at the top level of a module"))
+	}
+
+	evaluate_statement(expectedStatement.Declaration, scope)
+
+	switch declaration := expectedStatement.Declaration.(type) {
+	case ast.VariableDeclarationStatement:
+		scope.Export(declaration.Identifier)
+	case ast.MultiVariableDeclarationStatement:
+		for _, variable := range declaration.Declarations {
+			scope.Export(variable.Identifier)
+		}
+	case ast.FunctionDeclarationStatment:
+		scope.Export(declaration.Identifier)
+	case ast.StructDeclarationStatement:
+		scope.Export(declaration.Identifier)
 	}
 }
 
@@ -480,6 +558,38 @@ func evaluate_struct_declaration_statement(statement ast.Statement, scope *Scope
 	}
 
 	storage := make(map[string]StructAttribute)
+
+	// An embedded struct is stored as a field named after it
+	for _, identifier := range expectedStatement.Embedded {
+		if _, exists := storage[identifier]; exists {
+			panic(fmt.Errorf("attribute '%s' already exists", identifier))
+		}
+
+		ref, err := scope.Resolve(identifier)
+		if err != nil {
+			panic(fmt.Errorf("cannot embed undefined struct '%s'", identifier))
+		}
+		embedded, ok := ref.Load().(*Struct)
+		if !ok {
+			panic(fmt.Errorf("cannot embed non-struct type '%s'", identifier))
+		}
+
+		storage[identifier] = StructAttribute{
+			Reference: NewVariableReference(identifier, false, NewStructInstance(embedded), embedded.Type()),
+		}
+	}
+
+	// The struct is declared before its members so that fields and methods can
+	// refer to it
+	_type := NewStructType(storage, expectedStatement.Embedded)
+	_type.identifier = expectedStatement.Identifier
+	structRef := NewStruct(expectedStatement.Identifier, _type)
+
+	err = scope.Declare(structRef)
+	if err != nil {
+		panic(err)
+	}
+
 	for _, property := range expectedStatement.Properties {
 		if _, exists := storage[property.Identifier]; exists {
 			panic(fmt.Errorf("attribute '%s' already exists", property.Identifier))
@@ -500,13 +610,39 @@ func evaluate_struct_declaration_statement(statement ast.Statement, scope *Scope
 			explicitType = defaultValue.Type()
 		}
 
+		var validator Function
+		if property.Validator != nil {
+			value := evaluate_expression(property.Validator, scope)
+			if ref, ok := value.(Reference); ok {
+				value = ref.Load()
+			}
+
+			var ok bool
+			if validator, ok = value.(Function); !ok {
+				panic(fmt.Errorf("validator of property '%s' must be a function", property.Identifier))
+			}
+		}
+
 		ref := NewVariableReference(property.Identifier, property.IsConst, defaultValue, explicitType)
+		tags := make(map[string][]string)
+		for _, tag := range property.Tags {
+			if _, exists := tags[tag.Name]; exists {
+				panic(fmt.Errorf("property '%s' has more than one '%s' tag", property.Identifier, tag.Name))
+			}
+			tags[tag.Name] = tag.Arguments
+		}
+
 		storage[property.Identifier] = StructAttribute{
-			Reference: ref,
-			isStatic:  property.IsStatic,
+			Reference:  ref,
+			isStatic:   property.IsStatic,
+			isRequired: property.IsRequired,
+			validator:  validator,
+			tags:       tags,
 		}
 	}
 
+	var constructor *FunctionValue
+
 	for _, method := range expectedStatement.Methods {
 		if _, exists := storage[method.Declaration.Identifier]; exists {
 			panic(fmt.Errorf("attribute '%s' already exists", method.Declaration.Identifier))
@@ -518,6 +654,33 @@ func evaluate_struct_declaration_statement(statement ast.Statement, scope *Scope
 			EvaluateType(method.Declaration.ReturnType, scope),
 			scope,
 		)
+
+		// Methods without an explicit receiver receive a pointer to the instance as self
+		if method.Declaration.Receiver != nil {
+			if method.IsStatic {
+				panic(fmt.Errorf("static method '%s' cannot declare a receiver", method.Declaration.Identifier))
+			}
+
+			identifier, receiver := evaluate_receiver(method.Declaration.Receiver)
+			if identifier != expectedStatement.Identifier {
+				panic(fmt.Errorf("method '%s' of struct '%s' cannot have a receiver of type '%s'",
+					method.Declaration.Identifier, expectedStatement.Identifier, identifier))
+			}
+			ptr.receiver = receiver
+		} else if !method.IsStatic {
+			ptr.receiver = &Receiver{identifier: "self", isPointer: true}
+		}
+
+		// The constructor receives a pointer to the instance being created
+		if method.Declaration.Identifier == "new" {
+			if method.IsStatic || !ptr.receiver.isPointer && method.Declaration.Receiver != nil {
+				panic(fmt.Errorf("constructor of struct '%s' must be an instance method with a pointer receiver", expectedStatement.Identifier))
+			}
+			ptr.receiver.isPointer = true
+			constructor = ptr
+			continue
+		}
+
 		ref := NewFunctionReference(method.Declaration.Identifier, ptr)
 		storage[method.Declaration.Identifier] = StructAttribute{
 			Reference: ref,
@@ -525,13 +688,8 @@ func evaluate_struct_declaration_statement(statement ast.Statement, scope *Scope
 		}
 	}
 
-	ref := NewStruct(
-		expectedStatement.Identifier,
-		NewStructType(storage),
-	)
-
-	err = scope.Declare(ref)
-	if err != nil {
-		panic(err)
-	}
+	_type = NewStructType(storage, expectedStatement.Embedded)
+	_type.identifier = expectedStatement.Identifier
+	_type.constructor = constructor
+	structRef._type = _type
 }
@@ -0,0 +1,250 @@
+package interpreter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// StreamType represents the type of a stream of text read or written a piece
+// at a time, like the standard input and output of the process
+type StreamType struct{}
+
+// StreamType implements the Type interface
+func (StreamType) String() string { return "stream" }
+func (s StreamType) Equals(other Type) bool {
+	_, ok := other.(StreamType)
+	return ok
+}
+func (s StreamType) DefaultValue() Value { return NewNil() }
+
+// Stream reads from a reader or writes to a writer. Streams are shared by
+// reference, copies read and write the same underlying stream.
+type Stream struct {
+	name    string
+	reader  *bufio.Reader
+	writer  io.Writer
+	closer  io.Closer
+	methods map[string]Function
+}
+
+func NewReadStream(name string, reader io.Reader) *Stream {
+	stream := &Stream{
+		name:    name,
+		reader:  bufio.NewReader(reader),
+		methods: make(map[string]Function),
+	}
+	stream.init_read_methods()
+	return stream
+}
+
+// NewReadCloseStream creates a read stream that can also be closed, for
+// input like a response body that holds on to a connection until it is
+func NewReadCloseStream(name string, reader io.ReadCloser) *Stream {
+	stream := NewReadStream(name, reader)
+	stream.closer = reader
+	stream.methods["close"] = NewNativeFunction(
+		func(args ...Value) Value {
+			if err := stream.closer.Close(); err != nil {
+				throw_native_error(stream.name, err)
+			}
+			return NewNil()
+		},
+		[]Type{},
+		PrimitiveType{NilType},
+	)
+	return stream
+}
+
+func NewWriteStream(name string, writer io.Writer) *Stream {
+	stream := &Stream{
+		name:    name,
+		writer:  writer,
+		methods: make(map[string]Function),
+	}
+	stream.init_write_methods()
+	return stream
+}
+
+// Stream implements the Value interface
+func (*Stream) Type() Type       { return StreamType{} }
+func (s *Stream) Clone() Value   { return s }
+func (s *Stream) String() string { return fmt.Sprintf("stream(%s)", s.name) }
+
+// read_line returns the next line without its line ending, false at the end
+// of the stream
+func (s *Stream) read_line() (string, bool) {
+	return read_line(s.reader, s.name)
+}
+
+// read_line reads the next line of a reader without its line ending, false
+// at the end of the input
+func read_line(reader *bufio.Reader, context string) (string, bool) {
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		throw_native_error(context, err)
+	}
+	if err == io.EOF && line == "" {
+		return "", false
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return line, true
+}
+
+func (s *Stream) init_read_methods() {
+	s.methods["read_line"] = NewNativeFunction(
+		func(args ...Value) Value {
+			line, ok := s.read_line()
+			if !ok {
+				return NewNil()
+			}
+			return NewString(line)
+		},
+		[]Type{},
+		PrimitiveType{AnyType},
+	)
+
+	s.methods["read_all"] = NewNativeFunction(
+		func(args ...Value) Value {
+			data, err := io.ReadAll(s.reader)
+			if err != nil {
+				throw_native_error(s.name, err)
+			}
+			return NewString(string(data))
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	s.methods["lines"] = NewNativeFunction(
+		func(args ...Value) Value {
+			lines := make([]Value, 0)
+			for {
+				line, ok := s.read_line()
+				if !ok {
+					break
+				}
+				lines = append(lines, NewString(line))
+			}
+			return NewSlice(lines, PrimitiveType{StringType})
+		},
+		[]Type{},
+		NewSliceType(PrimitiveType{StringType}),
+	)
+
+	s.methods["each_line"] = NewNativeFunction(
+		func(args ...Value) Value {
+			each_line(args[0], s.read_line)
+			return NewNil()
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+}
+
+// each_line calls the callback with every line read and its number, until
+// the input ends or the callback returns false
+func each_line(value Value, read func() (string, bool)) {
+	callback, ok := value.(Function)
+	if !ok {
+		throw_error(ErrorCodeInvalid, "expected a callback function but got %v", value.Type())
+	}
+
+	for number := 1; ; number++ {
+		line, ok := read()
+		if !ok {
+			break
+		}
+
+		args := []Value{NewString(line), NewNumber(float64(number))}
+		result, err := callback.Call(args[:min(function_arity(callback), len(args))]...)
+		if err != nil {
+			panic(err)
+		}
+		if stop, ok := result.(Boolean); ok && !stop.Value() {
+			break
+		}
+	}
+}
+
+func (s *Stream) init_write_methods() {
+	s.methods["write"] = NewNativeFunction(
+		func(args ...Value) Value {
+			if _, err := io.WriteString(s.writer, args[0].(String).Value()); err != nil {
+				throw_native_error(s.name, err)
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{NilType},
+	)
+
+	s.methods["print"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			write_values(s.writer, args...)
+			return NewNil()
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	s.methods["println"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			write_values(s.writer, args...)
+			fmt.Fprint(s.writer, "\n")
+			return NewNil()
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	s.methods["printf"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			fmt.Fprint(s.writer, sprintf_function(args...).(String).Value())
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+}
+
+// The standard streams of the process, shared by every run so that input
+// buffered by one read is not lost to the next
+var (
+	stdin_stream  = NewReadStream("stdin", os.Stdin)
+	stdout_stream = NewWriteStream("stdout", os.Stdout)
+	stderr_stream = NewWriteStream("stderr", os.Stderr)
+)
+
+// exit_hooks are the callbacks registered with os.on_exit, run last first
+// when the program ends
+var exit_hooks = make([]Function, 0)
+
+// run_exit_hooks runs and clears the exit hooks, passing them the exit code
+// when they take it. It returns the first error a hook throws, the others
+// still run.
+func run_exit_hooks(code int) (err error) {
+	hooks := exit_hooks
+	exit_hooks = make([]Function, 0)
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		func() {
+			defer func() {
+				if r := recover(); r != nil && err == nil {
+					err = uncaught(r)
+				}
+			}()
+
+			args := []Value{NewNumber(float64(code))}
+			if _, hookErr := hook.Call(args[:min(function_arity(hook), len(args))]...); hookErr != nil && err == nil {
+				err = uncaught(hookErr)
+			}
+		}()
+	}
+	return err
+}
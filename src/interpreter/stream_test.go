@@ -0,0 +1,12 @@
+package interpreter
+
+import "testing"
+
+func TestStreamWriteKeepsRuntimeText(t *testing.T) {
+	expect_output(t, `
+import os from "os"
+const path = "C:\\new\\table"
+os.stdout.write(path + "|" + "\d" + "\n")
+os.stdout.write(path)
+`, "C:\\new\\table|\\d\nC:\\new\\table")
+}
@@ -2,18 +2,28 @@ package interpreter
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/table-harmony/HarmonyLang/src/ast"
 )
 
 type StructAttribute struct {
 	Reference
-	isStatic bool
+	isStatic   bool
+	isRequired bool
+	validator  Function
+	tags       map[string][]string
 }
 
 type StructType struct {
-	storage map[string]StructAttribute
+	identifier  string
+	storage     map[string]StructAttribute
+	embedded    []string
+	constructor *FunctionValue
 }
 
-func NewStructType(storage map[string]StructAttribute) StructType {
+func NewStructType(storage map[string]StructAttribute, embedded []string) StructType {
 	for name, attr := range storage {
 		if attr.Reference == nil {
 			panic(fmt.Sprintf("struct attribute '%s' has nil reference", name))
@@ -31,10 +41,25 @@ func NewStructType(storage map[string]StructAttribute) StructType {
 		}
 	}
 
-	return StructType{storage}
+	for _, name := range embedded {
+		attr, exists := storage[name]
+		if !exists {
+			panic(fmt.Sprintf("embedded struct '%s' has no attribute", name))
+		}
+		if _, ok := attr.Reference.Type().(StructType); !ok {
+			panic(fmt.Sprintf("embedded attribute '%s' is not a struct", name))
+		}
+	}
+
+	return StructType{storage: storage, embedded: embedded}
 }
 
 func (s StructType) String() string {
+	// Named types print their name, their members may refer back to them
+	if s.identifier != "" {
+		return s.identifier
+	}
+
 	str := ""
 	for identifier, item := range s.storage {
 		if item.isStatic {
@@ -56,18 +81,92 @@ func (s StructType) Equals(other Type) bool {
 	if !ok {
 		return false
 	}
-	if len(s.storage) != len(otherStruct.storage) {
+	// Types of the same declaration share their storage, this also stops
+	// methods that refer to their own struct from recursing forever
+	if reflect.ValueOf(s.storage).Pointer() == reflect.ValueOf(otherStruct.storage).Pointer() {
+		return true
+	}
+	if len(s.storage) != len(otherStruct.storage) || len(s.embedded) != len(otherStruct.embedded) {
 		return false
 	}
+	for i, name := range s.embedded {
+		if otherStruct.embedded[i] != name {
+			return false
+		}
+	}
 	for key, attr := range s.storage {
 		otherAttr, exists := otherStruct.storage[key]
 		if !exists || !attr.Reference.Load().Type().Equals(otherAttr.Reference.Load().Type()) || attr.isStatic != otherAttr.isStatic {
 			return false
 		}
 	}
+
+	// Methods promoted from embedded structs are part of the type as well
+	methods, otherMethods := s.MethodSet(), otherStruct.MethodSet()
+	if len(methods) != len(otherMethods) {
+		return false
+	}
+	for name, method := range methods {
+		otherMethod, exists := otherMethods[name]
+		if !exists || !method.Equals(otherMethod) {
+			return false
+		}
+	}
 	return true
 }
 
+// MethodSet returns the types of the instance methods of the struct including
+// the ones promoted from embedded structs
+func (s StructType) MethodSet() map[string]Type {
+	methods := make(map[string]Type)
+	for name, attr := range s.Methods() {
+		methods[name] = attr.Reference.Type()
+	}
+	return methods
+}
+
+// Methods returns the instance methods of the struct including the ones
+// promoted from embedded structs. A method declared at a shallower depth
+// shadows deeper ones, ambiguous methods at the same depth are left out.
+func (s StructType) Methods() map[string]StructAttribute {
+	methods := make(map[string]StructAttribute)
+	ambiguous := make(map[string]bool)
+
+	level := []StructType{s}
+	for depth := 0; len(level) > 0; depth++ {
+		found := make(map[string]StructAttribute)
+		next := make([]StructType, 0)
+
+		for _, current := range level {
+			for name, attr := range current.storage {
+				if _, ok := attr.Reference.(*FunctionReference); !ok || attr.isStatic {
+					continue
+				}
+				if _, exists := methods[name]; exists || ambiguous[name] {
+					continue
+				}
+				if _, exists := found[name]; exists {
+					ambiguous[name] = true
+				}
+				found[name] = attr
+			}
+
+			for _, name := range current.embedded {
+				next = append(next, current.storage[name].Reference.Type().(StructType))
+			}
+		}
+
+		for name, method := range found {
+			if !ambiguous[name] {
+				methods[name] = method
+			}
+		}
+		level = next
+	}
+
+	return methods
+}
+
 type Struct struct {
 	identifier string
 	_type      StructType
@@ -123,16 +222,32 @@ func (s StructInstantiation) Type() Type { return s.constructor._type }
 func (s StructInstantiation) Clone() Value {
 	newStorage := make(map[string]Reference)
 	for name, ref := range s.storage {
-		newStorage[name] = NewVariableReference(
-			name,
-			ref.(*VariableReference).isConstant,
-			ref.Load().Clone(),
-			ref.Type(),
+		field := ref
+		validated, isField := ref.(*FieldReference)
+		if isField {
+			field = validated.VariableReference
+		}
+
+		newStorage[name] = NewFieldReference(
+			NewVariableReference(
+				name,
+				field.(*VariableReference).isConstant,
+				ref.Load().Clone(),
+				ref.Type(),
+			),
+			s.constructor._type.storage[name],
 		)
+		if clone, ok := newStorage[name].(*FieldReference); ok && isField {
+			clone.assigned = validated.assigned
+		}
 	}
 	return NewStructInstaniation(s.constructor, newStorage)
 }
 func (s StructInstantiation) String() string {
+	if str, ok := s.to_string(); ok {
+		return str
+	}
+
 	str := fmt.Sprintf("%s {\n", s.constructor.identifier)
 	for name, ref := range s.storage {
 		str += fmt.Sprintf("  %s: %v\n", name, ref.Load())
@@ -140,3 +255,250 @@ func (s StructInstantiation) String() string {
 	str += "}"
 	return str
 }
+
+// Receiver describes how a method receives the instance it is called on. A
+// value receiver gets a copy of the instance, a pointer receiver gets a
+// pointer to it so mutations are visible to the caller.
+type Receiver struct {
+	identifier string
+	isPointer  bool
+}
+
+// evaluate_receiver returns the name of the struct a method is declared on
+// and how it receives its instance
+func evaluate_receiver(receiver *ast.Parameter) (string, *Receiver) {
+	_type := receiver.Type
+	isPointer := false
+	if pointer, ok := _type.(ast.PointerType); ok {
+		_type = pointer.Target
+		isPointer = true
+	}
+
+	symbol, ok := _type.(ast.SymbolType)
+	if !ok {
+		panic(fmt.Sprintf("method receiver '%s' must be a struct or a pointer to a struct", receiver.Name))
+	}
+
+	return symbol.Value, &Receiver{
+		identifier: receiver.Name,
+		isPointer:  isPointer,
+	}
+}
+
+// BoundMethod is a method together with the receiver it was accessed on
+type BoundMethod struct {
+	method   FunctionValue
+	receiver Value
+}
+
+// bind_method binds a method to an instance. Value receivers get a copy taken
+// when the method is accessed, pointer receivers point at the reference
+// holding the instance when it is addressable.
+func bind_method(method *FunctionValue, owner StructInstantiation, ownerRef Reference) BoundMethod {
+	if method.receiver == nil || !method.receiver.isPointer {
+		return BoundMethod{*method, owner.Clone()}
+	}
+
+	if ownerRef == nil {
+		ownerRef = &VariableReference{
+			identifier:   method.receiver.identifier,
+			value:        owner,
+			explicitType: owner.Type(),
+		}
+	}
+	return BoundMethod{*method, NewPointer(ownerRef)}
+}
+
+// BoundMethod implements the Value interface
+func (m BoundMethod) Type() Type     { return m.method.Type() }
+func (m BoundMethod) Clone() Value   { return m }
+func (m BoundMethod) String() string { return m.method.String() }
+
+// BoundMethod implements the Function interface
+func (m BoundMethod) Call(args ...Value) (Value, error) {
+	// Every call of a value receiver gets a copy of its own
+	if m.method.receiver == nil || !m.method.receiver.isPointer {
+		return m.method.call(m.receiver.Clone(), args...)
+	}
+	return m.method.call(m.receiver, args...)
+}
+
+// NewStructInstance creates an instance of a struct with every field set to
+// its default value
+func NewStructInstance(constructor *Struct) StructInstantiation {
+	storage := make(map[string]Reference)
+
+	for identifier, property := range constructor._type.storage {
+		if !property.isStatic {
+			if ref, ok := property.Reference.(*VariableReference); ok {
+				var defaultValue Value
+				if ref.value != nil {
+					defaultValue = ref.value.Clone()
+				} else {
+					defaultValue = ref.explicitType.DefaultValue()
+				}
+
+				storage[identifier] = NewFieldReference(
+					NewVariableReference(
+						identifier,
+						ref.isConstant,
+						defaultValue,
+						ref.explicitType,
+					),
+					property,
+				)
+			}
+		}
+	}
+
+	return NewStructInstaniation(*constructor, storage)
+}
+
+// Promote finds the attribute with the given name in the instance or the
+// structs it embeds, searching breadth first so that shallower attributes
+// shadow deeper ones. It returns the instance holding the attribute along with
+// the reference to that instance.
+func (s StructInstantiation) Promote(name string, ref Reference) (StructInstantiation, Reference, StructAttribute, bool) {
+	type candidate struct {
+		instance StructInstantiation
+		ref      Reference
+	}
+
+	level := []candidate{{s, ref}}
+	for len(level) > 0 {
+		matches := make([]candidate, 0)
+		next := make([]candidate, 0)
+
+		for _, current := range level {
+			if _, exists := current.instance.constructor._type.storage[name]; exists {
+				matches = append(matches, current)
+			}
+
+			for _, embedded := range current.instance.constructor._type.embedded {
+				embeddedRef, exists := current.instance.storage[embedded]
+				if !exists {
+					continue
+				}
+				if instance, ok := embeddedRef.Load().(StructInstantiation); ok {
+					next = append(next, candidate{instance, embeddedRef})
+				}
+			}
+		}
+
+		if len(matches) > 1 {
+			panic(fmt.Sprintf("ambiguous selector '%s' on struct %s", name, s.constructor.identifier))
+		}
+		if len(matches) == 1 {
+			match := matches[0]
+			return match.instance, match.ref, match.instance.constructor._type.storage[name], true
+		}
+
+		level = next
+	}
+
+	return s, ref, StructAttribute{}, false
+}
+
+// FieldReference is a field of a struct instance with a validation hook that
+// runs whenever the field is assigned. It remembers whether the field was
+// assigned so that required fields can be checked after a constructor runs.
+type FieldReference struct {
+	*VariableReference
+	validator Function
+	assigned  bool
+}
+
+// NewFieldReference wraps the field if its attribute declares a validator or
+// is required
+func NewFieldReference(ref *VariableReference, attr StructAttribute) Reference {
+	if attr.validator == nil && !attr.isRequired {
+		return ref
+	}
+	return &FieldReference{ref, attr.validator, false}
+}
+
+// FieldReference implements the Reference interface
+func (f *FieldReference) Store(v Value) error {
+	if f.validator != nil {
+		validate_field(f.validator, f.identifier, v)
+	}
+	if err := f.VariableReference.Store(v); err != nil {
+		return err
+	}
+	f.assigned = true
+	return nil
+}
+func (f *FieldReference) Address() Value { return NewPointer(f) }
+
+// validate_field calls the validator of a field with a new value. The
+// validator rejects the value by throwing or by returning false.
+func validate_field(validator Function, field string, value Value) {
+	result, err := validator.Call(value)
+	if err != nil {
+		panic(err)
+	}
+
+	if valid, ok := result.(Boolean); ok && !valid.Value() {
+		panic(NewThrowError(NewStructuredError(
+			fmt.Sprintf("invalid value %s for field '%s'", format_value(value, true), field),
+			ErrorCodeInvalid,
+			nil,
+			[]MapEntry{
+				{key: NewString("field"), value: NewString(field)},
+				{key: NewString("value"), value: value},
+			},
+		)))
+	}
+}
+
+// construct creates an instance of the struct by calling its constructor with
+// a pointer to an instance holding the default values
+func (s *Struct) construct(args []Value) Value {
+	if s._type.constructor == nil {
+		panic(fmt.Sprintf("struct %s has no constructor, declare fn new() or use a struct literal", s.identifier))
+	}
+
+	instance := &VariableReference{
+		identifier:   s.identifier,
+		value:        NewStructInstance(s),
+		explicitType: s._type,
+	}
+
+	if _, err := s._type.constructor.call(NewPointer(instance), args...); err != nil {
+		panic(err)
+	}
+
+	result := instance.Load()
+	if created, ok := result.(StructInstantiation); ok {
+		s.check_fields(created, func(name string) bool {
+			field, ok := created.storage[name].(*FieldReference)
+			return ok && field.assigned
+		})
+	}
+	return result
+}
+
+// check_fields makes sure that every required field of a new instance was set
+// and validates the default values of the fields that were not
+func (s *Struct) check_fields(instance StructInstantiation, assigned func(name string) bool) {
+	names := make([]string, 0, len(s._type.storage))
+	for name, attr := range s._type.storage {
+		if _, ok := attr.Reference.(*VariableReference); ok && !attr.isStatic {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		attr := s._type.storage[name]
+		if assigned(name) {
+			continue
+		}
+		if attr.isRequired {
+			throw_error(ErrorCodeInvalid, "missing required property '%s' in %s", name, s.identifier)
+		}
+		if attr.validator != nil {
+			validate_field(attr.validator, name, instance.storage[name].Load())
+		}
+	}
+}
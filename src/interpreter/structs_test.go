@@ -0,0 +1,104 @@
+package interpreter
+
+import "testing"
+
+const counter_source = `
+import fmt from "fmt"
+struct Counter {
+    count: number
+
+    fn (self: Counter) peek() -> number {
+        return self.count
+    }
+
+    fn reset() {
+        self.count = 0
+    }
+
+    fn (self: Counter) bump() -> number {
+        self.count++
+        return self.count
+    }
+
+    fn (self: *Counter) increment() {
+        self.count++
+    }
+}
+let counter = new Counter{ count: 0 }
+`
+
+func TestValueReceiverDoesNotMutateCaller(t *testing.T) {
+	expect_output(t, counter_source+`
+fmt.println(counter.bump(), counter.bump(), counter.count)
+`, "1 1 0")
+}
+
+func TestPointerReceiverMutatesCaller(t *testing.T) {
+	expect_output(t, counter_source+`
+counter.increment()
+const increment = counter.increment
+increment()
+fmt.println(counter.count)
+`, "2")
+}
+
+func TestBoundValueMethodKeepsItsCopy(t *testing.T) {
+	expect_output(t, counter_source+`
+const peek = counter.peek
+const bump = counter.bump
+counter.increment()
+counter.count = 10
+fmt.println(peek(), bump(), bump(), counter.peek())
+`, "0 1 1 10")
+}
+
+func TestMethodWithoutReceiverMutatesCaller(t *testing.T) {
+	expect_output(t, counter_source+`
+counter.increment()
+counter.reset()
+const reset = counter.reset
+counter.increment()
+reset()
+fmt.println(counter.count)
+`, "0")
+}
+
+const task_source = `
+import fmt from "fmt"
+fn in_range(priority: number) -> bool {
+    return priority >= 1 && priority <= 5
+}
+struct Task {
+    required title: string
+    validate(in_range) priority: number
+
+    fn new(title: string) {
+        if title != "" {
+            self.title = title
+        }
+        self.priority = 3
+    }
+}
+fn check(create: fn() -> Task) {
+    try {
+        fmt.println(create().priority)
+    } catch (e: error) {
+        fmt.println(e.code(), e.message())
+    }
+}
+`
+
+func TestConstructorMustSetRequiredFields(t *testing.T) {
+	expect_output(t, task_source+`
+check(fn() -> Task { return new Task("docs") })
+check(fn() -> Task { return new Task("") })
+`, "3\ninvalid missing required property 'title' in Task")
+}
+
+func TestDefaultValuesAreValidated(t *testing.T) {
+	expect_output(t, task_source+`
+check(fn() -> Task { return new Task{ title: "docs", priority: 2 } })
+check(fn() -> Task { return new Task{ title: "docs" } })
+check(fn() -> Task { return new Task{ priority: 2 } })
+`, "2\ninvalid invalid value 0 for field 'priority'\ninvalid missing required property 'title' in Task")
+}
@@ -0,0 +1,98 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// throw_toml_error throws a syntax error for a TOML document with the line
+// and offset of the problem
+func throw_toml_error(err error) {
+	fields := make([]MapEntry, 0)
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		fields = append(fields,
+			MapEntry{NewString("line"), NewNumber(float64(parseErr.Position.Line))},
+			MapEntry{NewString("offset"), NewNumber(float64(parseErr.Position.Start))},
+		)
+	}
+
+	message := "TOML parse error: " + strings.TrimPrefix(err.Error(), "toml: ")
+	panic(NewThrowError(NewStructuredError(message, ErrorCodeSyntax, nil, fields)))
+}
+
+// toml_stringify encodes a map as a TOML document. Keys are written in sorted
+// order with plain values before tables.
+func toml_stringify(value Value, options Value) string {
+	document, ok := native_integers(convert_to_native(value)).(map[string]interface{})
+	if !ok {
+		throw_error(ErrorCodeInvalid, "toml: a document must be a map but got %v", value.Type())
+	}
+
+	var buffer bytes.Buffer
+	encoder := toml.NewEncoder(&buffer)
+	if options != nil {
+		opts, ok := options.(Map)
+		if !ok {
+			panic(fmt.Sprintf("toml options must be a map but got %v", options.Type()))
+		}
+		for _, entry := range *opts.entries {
+			switch entry.key.String() {
+			case "indent":
+				switch indent := entry.value.(type) {
+				case Number:
+					encoder.Indent = strings.Repeat(" ", int(indent.Value()))
+				case String:
+					encoder.Indent = indent.Value()
+				default:
+					panic(fmt.Sprintf("toml indent must be a number or a string but got %v", entry.value.Type()))
+				}
+			default:
+				panic(fmt.Sprintf("unknown toml option: %s", entry.key.String()))
+			}
+		}
+	}
+
+	if err := encoder.Encode(document); err != nil {
+		throw_error(ErrorCodeInvalid, "toml: %s", strings.TrimPrefix(err.Error(), "toml: "))
+	}
+	return buffer.String()
+}
+
+func init_toml_module() Module {
+	module := NewModule()
+
+	// parse(toml: string): map[string -> any]
+	// Purpose: Parses a TOML document into a map. Dates and times are returned as strings
+	module.exports["parse"] = NewNativeFunction(
+		func(args ...Value) Value {
+			document := make(map[string]interface{})
+			if _, err := toml.Decode(args[0].(String).Value(), &document); err != nil {
+				throw_toml_error(err)
+			}
+			return convert_to_value(document)
+		},
+		[]Type{PrimitiveType{StringType}},
+		NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
+	)
+
+	// stringify(document: map, options?: map): string
+	// Purpose: Encodes a map as a TOML document
+	module.exports["stringify"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			var options Value
+			if len(args) > 1 {
+				options = args[1]
+			}
+			return NewString(toml_stringify(args[0], options))
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{StringType},
+	)
+
+	return *module
+}
@@ -0,0 +1,1074 @@
+package interpreter
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XmlType represents the type of an XML element
+type XmlType struct{}
+
+// XmlType implements the Type interface
+func (XmlType) String() string { return "xml" }
+func (x XmlType) Equals(other Type) bool {
+	_, ok := other.(XmlType)
+	return ok
+}
+func (x XmlType) DefaultValue() Value { return NewNil() }
+
+type XmlNodeKind int
+
+const (
+	XmlElementNode XmlNodeKind = iota
+	XmlTextNode
+	XmlCommentNode
+)
+
+// XmlAttribute is an attribute of an element as written in the document,
+// prefix included
+type XmlAttribute struct {
+	prefix string
+	name   string
+	value  string
+}
+
+func (a XmlAttribute) qualified_name() string {
+	if a.prefix == "" {
+		return a.name
+	}
+	return a.prefix + ":" + a.name
+}
+
+// XmlElement is a node of an XML document. Elements hold their attributes
+// and child nodes, text and comment nodes only hold their text. Elements are
+// shared by reference, so changes made through one variable are visible
+// through all of them.
+type XmlElement struct {
+	kind       XmlNodeKind
+	prefix     string
+	name       string
+	text       string
+	attributes []XmlAttribute
+	children   []*XmlElement
+	parent     *XmlElement
+	methods    map[string]Function
+}
+
+func NewXmlElement(qualifiedName string) *XmlElement {
+	prefix, name := xml_split_name(qualifiedName)
+	element := &XmlElement{
+		kind:    XmlElementNode,
+		prefix:  prefix,
+		name:    name,
+		methods: make(map[string]Function),
+	}
+	element.init_methods()
+	return element
+}
+
+func new_xml_text(text string) *XmlElement {
+	return &XmlElement{kind: XmlTextNode, text: text}
+}
+
+func new_xml_comment(text string) *XmlElement {
+	return &XmlElement{kind: XmlCommentNode, text: text}
+}
+
+// XmlElement implements the Value interface
+func (*XmlElement) Type() Type        { return XmlType{} }
+func (e *XmlElement) Clone() Value    { return e }
+func (e *XmlElement) String() string  { return xml_stringify(e, xml_options{}) }
+func (e *XmlElement) Parent() Value   { return xml_value(e.parent) }
+func (e *XmlElement) IsElement() bool { return e.kind == XmlElementNode }
+
+// qualified_name returns the name of the element as written, prefix included
+func (e *XmlElement) qualified_name() string {
+	if e.prefix == "" {
+		return e.name
+	}
+	return e.prefix + ":" + e.name
+}
+
+// Namespace returns the namespace URI bound to the element's prefix by the
+// xmlns attributes of the element or its ancestors
+func (e *XmlElement) Namespace() string {
+	uri, _ := e.lookup_namespace(e.prefix)
+	return uri
+}
+
+func (e *XmlElement) lookup_namespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return "http://www.w3.org/XML/1998/namespace", true
+	}
+
+	for element := e; element != nil; element = element.parent {
+		for _, attr := range element.attributes {
+			if prefix == "" && attr.prefix == "" && attr.name == "xmlns" {
+				return attr.value, true
+			}
+			if prefix != "" && attr.prefix == "xmlns" && attr.name == prefix {
+				return attr.value, true
+			}
+		}
+	}
+	return "", prefix == ""
+}
+
+// Attr returns the value of the attribute with the given qualified name
+func (e *XmlElement) Attr(name string) (string, bool) {
+	for _, attr := range e.attributes {
+		if attr.qualified_name() == name {
+			return attr.value, true
+		}
+	}
+	return "", false
+}
+
+func (e *XmlElement) SetAttr(name string, value string) {
+	for i, attr := range e.attributes {
+		if attr.qualified_name() == name {
+			e.attributes[i].value = value
+			return
+		}
+	}
+
+	prefix, local := xml_split_name(name)
+	e.attributes = append(e.attributes, XmlAttribute{prefix, local, value})
+}
+
+func (e *XmlElement) RemoveAttr(name string) bool {
+	for i, attr := range e.attributes {
+		if attr.qualified_name() == name {
+			e.attributes = append(e.attributes[:i], e.attributes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Elements returns the child elements, skipping text and comments
+func (e *XmlElement) Elements() []*XmlElement {
+	elements := make([]*XmlElement, 0, len(e.children))
+	for _, child := range e.children {
+		if child.IsElement() {
+			elements = append(elements, child)
+		}
+	}
+	return elements
+}
+
+// Text returns the text of the node and all its descendants
+func (e *XmlElement) Text() string {
+	if e.kind != XmlElementNode {
+		if e.kind == XmlTextNode {
+			return e.text
+		}
+		return ""
+	}
+
+	var builder strings.Builder
+	for _, child := range e.children {
+		builder.WriteString(child.Text())
+	}
+	return builder.String()
+}
+
+// Append adds a node as the last child of the element, detaching it from its
+// previous parent
+func (e *XmlElement) Append(child *XmlElement) {
+	for ancestor := e; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor == child {
+			throw_error(ErrorCodeInvalid, "cannot append <%s> to itself or one of its descendants", child.qualified_name())
+		}
+	}
+
+	child.Remove()
+	child.parent = e
+	e.children = append(e.children, child)
+}
+
+// Remove detaches the node from its parent
+func (e *XmlElement) Remove() {
+	if e.parent == nil {
+		return
+	}
+
+	siblings := e.parent.children
+	for i, sibling := range siblings {
+		if sibling == e {
+			e.parent.children = append(siblings[:i:i], siblings[i+1:]...)
+			break
+		}
+	}
+	e.parent = nil
+}
+
+func (e *XmlElement) init_methods() {
+	e.methods["name"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(e.qualified_name())
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	e.methods["local_name"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(e.name)
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	e.methods["prefix"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(e.prefix)
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	e.methods["namespace"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(e.Namespace())
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	e.methods["attr"] = NewNativeFunction(
+		func(args ...Value) Value {
+			if value, exists := e.Attr(args[0].(String).Value()); exists {
+				return NewString(value)
+			}
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{AnyType},
+	)
+
+	e.methods["attrs"] = NewNativeFunction(
+		func(args ...Value) Value {
+			entries := make([]MapEntry, len(e.attributes))
+			for i, attr := range e.attributes {
+				entries[i] = MapEntry{NewString(attr.qualified_name()), NewString(attr.value)}
+			}
+			return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{StringType})
+		},
+		[]Type{},
+		NewMapType(PrimitiveType{StringType}, PrimitiveType{StringType}),
+	)
+
+	e.methods["set_attr"] = NewNativeFunction(
+		func(args ...Value) Value {
+			e.SetAttr(args[0].(String).Value(), format_value(args[1], false))
+			return NewNil()
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	e.methods["remove_attr"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewBoolean(e.RemoveAttr(args[0].(String).Value()))
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{BooleanType},
+	)
+
+	e.methods["text"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewString(e.Text())
+		},
+		[]Type{},
+		PrimitiveType{StringType},
+	)
+
+	e.methods["children"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return xml_elements_slice(e.Elements())
+		},
+		[]Type{},
+		NewSliceType(XmlType{}),
+	)
+
+	e.methods["parent"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return e.Parent()
+		},
+		[]Type{},
+		PrimitiveType{AnyType},
+	)
+
+	e.methods["append"] = NewNativeFunction(
+		func(args ...Value) Value {
+			e.Append(xml_node(args[0]))
+			return NewNil()
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{NilType},
+	)
+
+	e.methods["remove"] = NewNativeFunction(
+		func(args ...Value) Value {
+			e.Remove()
+			return NewNil()
+		},
+		[]Type{},
+		PrimitiveType{NilType},
+	)
+
+	e.methods["find"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			results := xml_query(e, args[0].(String).Value(), xml_query_namespaces(args[1:]))
+			if len(results) == 0 {
+				return NewNil()
+			}
+			return results[0]
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		PrimitiveType{AnyType},
+	)
+
+	e.methods["find_all"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			results := xml_query(e, args[0].(String).Value(), xml_query_namespaces(args[1:]))
+			return NewSlice(results, PrimitiveType{AnyType})
+		},
+		[]Type{PrimitiveType{StringType}, PrimitiveType{AnyType}},
+		NewSliceType(PrimitiveType{AnyType}),
+	)
+
+	e.methods["to_map"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return xml_to_map(e)
+		},
+		[]Type{},
+		NewMapType(PrimitiveType{StringType}, PrimitiveType{AnyType}),
+	)
+
+	e.methods["to_string"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			var options Value
+			if len(args) > 0 {
+				options = args[0]
+			}
+			return NewString(xml_stringify(e, xml_stringify_options(options)))
+		},
+		[]Type{PrimitiveType{AnyType}},
+		PrimitiveType{StringType},
+	)
+}
+
+// xml_split_name splits a qualified name into its prefix and local name
+func xml_split_name(name string) (string, string) {
+	if prefix, local, found := strings.Cut(name, ":"); found {
+		return prefix, local
+	}
+	return "", name
+}
+
+func xml_value(element *XmlElement) Value {
+	if element == nil {
+		return NewNil()
+	}
+	return element
+}
+
+func xml_elements_slice(elements []*XmlElement) Value {
+	values := make([]Value, len(elements))
+	for i, element := range elements {
+		values[i] = element
+	}
+	return NewSlice(values, XmlType{})
+}
+
+// xml_node converts a value appended to an element to a node. Elements are
+// appended as they are, anything else becomes a text node.
+func xml_node(value Value) *XmlElement {
+	switch value := value.(type) {
+	case *XmlElement:
+		return value
+	case String, Number, Boolean:
+		return new_xml_text(format_value(value, false))
+	default:
+		throw_error(ErrorCodeInvalid, "cannot append a value of type %v to an xml element", value.Type())
+		return nil
+	}
+}
+
+// throw_xml_syntax_error throws a syntax error pointing at a line of the
+// parsed document
+func throw_xml_syntax_error(line int, format string, args ...any) {
+	message := fmt.Sprintf("XML parse error: %s at line %d", fmt.Sprintf(format, args...), line)
+	panic(NewThrowError(NewStructuredError(message, ErrorCodeSyntax, nil, []MapEntry{
+		{NewString("line"), NewNumber(float64(line))},
+	})))
+}
+
+// xml_parse parses a document and returns its root element. Whitespace only
+// text between elements is dropped, processing instructions and directives
+// such as the XML declaration and DOCTYPE are skipped.
+func xml_parse(text string) *XmlElement {
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	line := func() int {
+		line, _ := decoder.InputPos()
+		return line
+	}
+
+	var root *XmlElement
+	stack := make([]*XmlElement, 0)
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				throw_xml_syntax_error(syntaxErr.Line, "%s", strings.TrimPrefix(syntaxErr.Msg, "XML syntax error: "))
+			}
+			throw_native_error("XML parse error", err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			element := NewXmlElement(token.Name.Local)
+			element.prefix = token.Name.Space
+			for _, attr := range token.Attr {
+				element.attributes = append(element.attributes, XmlAttribute{attr.Name.Space, attr.Name.Local, attr.Value})
+			}
+
+			if len(stack) > 0 {
+				stack[len(stack)-1].Append(element)
+			} else if root != nil {
+				throw_xml_syntax_error(line(), "unexpected second root element <%s>", element.qualified_name())
+			} else {
+				root = element
+			}
+			stack = append(stack, element)
+
+			if _, ok := element.lookup_namespace(element.prefix); !ok {
+				throw_xml_syntax_error(line(), "undeclared namespace prefix '%s'", element.prefix)
+			}
+			for _, attr := range element.attributes {
+				if attr.prefix == "" || attr.prefix == "xmlns" {
+					continue
+				}
+				if _, ok := element.lookup_namespace(attr.prefix); !ok {
+					throw_xml_syntax_error(line(), "undeclared namespace prefix '%s'", attr.prefix)
+				}
+			}
+
+		case xml.EndElement:
+			name := token.Name.Local
+			if token.Name.Space != "" {
+				name = token.Name.Space + ":" + name
+			}
+			if len(stack) == 0 {
+				throw_xml_syntax_error(line(), "unexpected end element </%s>", name)
+			}
+			if open := stack[len(stack)-1]; open.qualified_name() != name {
+				throw_xml_syntax_error(line(), "element <%s> closed by </%s>", open.qualified_name(), name)
+			}
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if strings.TrimSpace(string(token)) == "" {
+				continue
+			}
+			if len(stack) == 0 {
+				throw_xml_syntax_error(line(), "text outside of the root element")
+			}
+			stack[len(stack)-1].Append(new_xml_text(string(token)))
+
+		case xml.Comment:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Append(new_xml_comment(string(token)))
+			}
+		}
+	}
+
+	if len(stack) > 0 {
+		throw_xml_syntax_error(line(), "unexpected end of input, <%s> is not closed", stack[len(stack)-1].qualified_name())
+	}
+	if root == nil {
+		throw_xml_syntax_error(line(), "document has no root element")
+	}
+	return root
+}
+
+type xml_options struct {
+	indent      string
+	declaration bool
+}
+
+func xml_stringify_options(options Value) xml_options {
+	result := xml_options{}
+	if options == nil {
+		return result
+	}
+
+	opts, ok := options.(Map)
+	if !ok {
+		panic(fmt.Sprintf("xml options must be a map but got %v", options.Type()))
+	}
+	for _, entry := range *opts.entries {
+		switch entry.key.String() {
+		case "indent":
+			switch indent := entry.value.(type) {
+			case Number:
+				result.indent = strings.Repeat(" ", int(indent.Value()))
+			case String:
+				result.indent = indent.Value()
+			default:
+				panic(fmt.Sprintf("xml indent must be a number or a string but got %v", entry.value.Type()))
+			}
+		case "declaration":
+			declaration, ok := entry.value.(Boolean)
+			if !ok {
+				panic(fmt.Sprintf("xml option 'declaration' has the wrong type %v", entry.value.Type()))
+			}
+			result.declaration = declaration.Value()
+		default:
+			panic(fmt.Sprintf("unknown xml option: %s", entry.key.String()))
+		}
+	}
+	return result
+}
+
+var (
+	xml_text_escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xml_attr_escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;", "\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;")
+)
+
+// xml_stringify serializes an element. With an indent, elements that only
+// contain other elements put each child on its own line, elements with text
+// are written inline so that their text is kept as is.
+func xml_stringify(element *XmlElement, options xml_options) string {
+	var builder strings.Builder
+	if options.declaration {
+		builder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+		if options.indent != "" {
+			builder.WriteString("\n")
+		}
+	}
+	xml_write(&builder, element, options, 0)
+	return builder.String()
+}
+
+func xml_write(builder *strings.Builder, node *XmlElement, options xml_options, depth int) {
+	switch node.kind {
+	case XmlTextNode:
+		builder.WriteString(xml_text_escaper.Replace(node.text))
+		return
+	case XmlCommentNode:
+		builder.WriteString("<!--" + node.text + "-->")
+		return
+	}
+
+	builder.WriteString("<" + node.qualified_name())
+	for _, attr := range node.attributes {
+		builder.WriteString(" " + attr.qualified_name() + "=\"" + xml_attr_escaper.Replace(attr.value) + "\"")
+	}
+	if len(node.children) == 0 {
+		builder.WriteString("/>")
+		return
+	}
+	builder.WriteString(">")
+
+	pretty := options.indent != ""
+	for _, child := range node.children {
+		if child.kind == XmlTextNode {
+			pretty = false
+		}
+	}
+
+	for _, child := range node.children {
+		if pretty {
+			builder.WriteString("\n" + strings.Repeat(options.indent, depth+1))
+		}
+		xml_write(builder, child, options, depth+1)
+	}
+	if pretty {
+		builder.WriteString("\n" + strings.Repeat(options.indent, depth))
+	}
+	builder.WriteString("</" + node.qualified_name() + ">")
+}
+
+// xml_to_map converts an element to a map with a single key, the element's
+// name. An element with neither attributes nor child elements maps to its
+// text. Otherwise it maps to a map holding its attributes under "@name" keys,
+// its child elements under their names, with repeated elements collected in a
+// slice, and its text under "#text". Comments are dropped.
+func xml_to_map(element *XmlElement) Value {
+	return NewMap([]MapEntry{
+		{NewString(element.qualified_name()), xml_content_to_value(element)},
+	}, PrimitiveType{StringType}, PrimitiveType{AnyType})
+}
+
+func xml_content_to_value(element *XmlElement) Value {
+	elements := element.Elements()
+	if len(element.attributes) == 0 && len(elements) == 0 {
+		return NewString(element.Text())
+	}
+
+	entries := make([]MapEntry, 0)
+	for _, attr := range element.attributes {
+		entries = append(entries, MapEntry{NewString("@" + attr.qualified_name()), NewString(attr.value)})
+	}
+
+	positions := make(map[string]int)
+	for _, child := range elements {
+		name := child.qualified_name()
+		content := xml_content_to_value(child)
+
+		position, exists := positions[name]
+		if !exists {
+			positions[name] = len(entries)
+			entries = append(entries, MapEntry{NewString(name), content})
+			continue
+		}
+
+		if repeated, ok := entries[position].value.(Slice); ok {
+			*repeated.elements = append(*repeated.elements, content)
+		} else {
+			entries[position].value = NewSlice([]Value{entries[position].value, content}, PrimitiveType{AnyType})
+		}
+	}
+
+	text := ""
+	for _, child := range element.children {
+		if child.kind == XmlTextNode {
+			text += child.text
+		}
+	}
+	if text != "" {
+		entries = append(entries, MapEntry{NewString("#text"), NewString(text)})
+	}
+
+	return NewMap(entries, PrimitiveType{StringType}, PrimitiveType{AnyType})
+}
+
+// xml_from_map builds an element from a map following the convention of
+// xml_to_map. The map must have a single key naming the root element.
+func xml_from_map(value Value) *XmlElement {
+	root, ok := value.(Map)
+	if !ok || len(*root.entries) != 1 {
+		throw_error(ErrorCodeInvalid, "expected a map with a single key naming the root element")
+	}
+
+	entry := (*root.entries)[0]
+	element := NewXmlElement(xml_map_key(entry.key))
+	xml_content_from_value(element, entry.value)
+	return element
+}
+
+func xml_content_from_value(element *XmlElement, value Value) {
+	switch value := value.(type) {
+	case Nil:
+		return
+	case String, Number, Boolean:
+		element.Append(xml_node(value))
+	case Map:
+		for _, entry := range *value.entries {
+			key := xml_map_key(entry.key)
+			switch {
+			case key == "#text":
+				if _, ok := entry.value.(Nil); !ok {
+					element.Append(xml_node(entry.value))
+				}
+			case strings.HasPrefix(key, "@"):
+				element.SetAttr(key[1:], format_value(entry.value, false))
+			default:
+				for _, content := range elements_of(entry.value) {
+					child := NewXmlElement(key)
+					xml_content_from_value(child, content)
+					element.Append(child)
+				}
+			}
+		}
+	default:
+		throw_error(ErrorCodeInvalid, "cannot convert a value of type %v to the content of <%s>", value.Type(), element.qualified_name())
+	}
+}
+
+func xml_map_key(key Value) string {
+	name, ok := key.(String)
+	if !ok {
+		throw_error(ErrorCodeInvalid, "xml map keys must be strings but got %v", key.Type())
+	}
+	return name.Value()
+}
+
+// xml_from_value converts the value given to xml.stringify to an element
+func xml_from_value(value Value) *XmlElement {
+	if element, ok := value.(*XmlElement); ok {
+		return element
+	}
+	return xml_from_map(value)
+}
+
+// xml_step is a single step of a query path
+type xml_step struct {
+	axis       string
+	name       string
+	predicates []xml_predicate
+}
+
+// xml_predicate filters the nodes matched by a step. A position selects a
+// single node, otherwise the attribute, child element or text() selected by
+// target must exist and, if value is set, compare to it.
+type xml_predicate struct {
+	position int
+	last     bool
+	target   string
+	operator string
+	value    string
+}
+
+// xml_query evaluates a query path against an element. Paths support a
+// subset of XPath:
+//
+//	/feed/entry      steps from the root of the document
+//	entry/title      steps from the element
+//	//link           descendants at any depth
+//	*, ., ..         any element, the element itself, its parent
+//	@href, text()    attribute values and text, as the last step
+//	[2], [last()]    the node at a position, starting at 1
+//	[@rel='next']    nodes with an attribute, optionally equal to a value
+//	[title='Go']     nodes with a child element, optionally with the given text
+//
+// Prefixed names are matched against the prefix used in the document, or
+// against the namespace URI if the prefix is bound in namespaces.
+func xml_query(element *XmlElement, path string, namespaces map[string]string) []Value {
+	steps := xml_parse_path(path)
+
+	context := []*XmlElement{element}
+	if strings.HasPrefix(path, "/") {
+		root := element
+		for root.parent != nil {
+			root = root.parent
+		}
+		context = []*XmlElement{{kind: XmlElementNode, children: []*XmlElement{root}}}
+	}
+
+	for i, step := range steps {
+		last := i == len(steps)-1
+		if step.axis == "attribute" || step.axis == "text" {
+			if !last {
+				panic(fmt.Sprintf("invalid xml path '%s': %s must be the last step", path, step.name))
+			}
+			return xml_query_values(context, step)
+		}
+
+		next := make([]*XmlElement, 0)
+		seen := make(map[*XmlElement]bool)
+		for _, node := range context {
+			for _, match := range xml_apply_predicates(xml_step_candidates(node, step, namespaces), step.predicates) {
+				if !seen[match] {
+					seen[match] = true
+					next = append(next, match)
+				}
+			}
+		}
+		context = next
+	}
+
+	results := make([]Value, len(context))
+	for i, node := range context {
+		results[i] = node
+	}
+	return results
+}
+
+func xml_step_candidates(node *XmlElement, step xml_step, namespaces map[string]string) []*XmlElement {
+	candidates := make([]*XmlElement, 0)
+	switch step.axis {
+	case "self":
+		return append(candidates, node)
+	case "parent":
+		if node.parent != nil {
+			candidates = append(candidates, node.parent)
+		}
+		return candidates
+	case "descendant":
+		var walk func(*XmlElement)
+		walk = func(parent *XmlElement) {
+			for _, child := range parent.Elements() {
+				if xml_name_matches(child, step.name, namespaces) {
+					candidates = append(candidates, child)
+				}
+				walk(child)
+			}
+		}
+		walk(node)
+	default:
+		for _, child := range node.Elements() {
+			if xml_name_matches(child, step.name, namespaces) {
+				candidates = append(candidates, child)
+			}
+		}
+	}
+	return candidates
+}
+
+func xml_name_matches(element *XmlElement, name string, namespaces map[string]string) bool {
+	if name == "*" {
+		return true
+	}
+
+	prefix, local := xml_split_name(name)
+	if local != element.name && local != "*" {
+		return false
+	}
+	if prefix == "" {
+		return true
+	}
+	if uri, bound := namespaces[prefix]; bound {
+		return element.Namespace() == uri
+	}
+	return element.prefix == prefix
+}
+
+func xml_apply_predicates(nodes []*XmlElement, predicates []xml_predicate) []*XmlElement {
+	for _, predicate := range predicates {
+		if predicate.position > 0 || predicate.last {
+			position := predicate.position
+			if predicate.last {
+				position = len(nodes)
+			}
+			if position < 1 || position > len(nodes) {
+				nodes = nil
+			} else {
+				nodes = []*XmlElement{nodes[position-1]}
+			}
+			continue
+		}
+
+		filtered := make([]*XmlElement, 0)
+		for _, node := range nodes {
+			if xml_predicate_matches(node, predicate) {
+				filtered = append(filtered, node)
+			}
+		}
+		nodes = filtered
+	}
+	return nodes
+}
+
+func xml_predicate_matches(node *XmlElement, predicate xml_predicate) bool {
+	values := make([]string, 0)
+	switch {
+	case predicate.target == "text()":
+		values = append(values, node.Text())
+	case strings.HasPrefix(predicate.target, "@"):
+		if value, exists := node.Attr(predicate.target[1:]); exists {
+			values = append(values, value)
+		}
+	default:
+		for _, child := range node.Elements() {
+			if xml_name_matches(child, predicate.target, nil) {
+				values = append(values, child.Text())
+			}
+		}
+	}
+
+	if predicate.operator == "" {
+		return len(values) > 0
+	}
+	for _, value := range values {
+		if (value == predicate.value) == (predicate.operator == "=") {
+			return true
+		}
+	}
+	return false
+}
+
+func xml_query_values(context []*XmlElement, step xml_step) []Value {
+	results := make([]Value, 0)
+	for _, node := range context {
+		if step.axis == "text" {
+			for _, child := range node.children {
+				if child.kind == XmlTextNode {
+					results = append(results, NewString(child.text))
+				}
+			}
+			continue
+		}
+
+		for _, attr := range node.attributes {
+			if step.name == "*" || attr.qualified_name() == step.name {
+				results = append(results, NewString(attr.value))
+			}
+		}
+	}
+	return results
+}
+
+// xml_parse_path splits a query path into steps. Slashes inside predicates
+// and quoted values do not separate steps.
+func xml_parse_path(path string) []xml_step {
+	invalid := func(reason string) {
+		panic(fmt.Sprintf("invalid xml path '%s': %s", path, reason))
+	}
+
+	segments := make([]string, 0)
+	start, depth := 0, 0
+	var quote rune
+	for i, char := range path {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"':
+			quote = char
+		case char == '[':
+			depth++
+		case char == ']':
+			depth--
+		case char == '/' && depth == 0:
+			segments = append(segments, path[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 || depth != 0 {
+		invalid("unbalanced brackets or quotes")
+	}
+	segments = append(segments, path[start:])
+
+	if strings.HasPrefix(path, "/") {
+		segments = segments[1:]
+	}
+
+	steps := make([]xml_step, 0)
+	descendant := false
+	for i, segment := range segments {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			if i == len(segments)-1 || descendant {
+				invalid("empty step")
+			}
+			descendant = true
+			continue
+		}
+
+		step := xml_parse_step(segment, invalid)
+		if descendant {
+			if step.axis != "child" {
+				invalid("only element names can follow //")
+			}
+			step.axis = "descendant"
+		}
+		descendant = false
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		invalid("empty path")
+	}
+	return steps
+}
+
+func xml_parse_step(segment string, invalid func(string)) xml_step {
+	name := segment
+	predicates := make([]xml_predicate, 0)
+	if index := strings.Index(segment, "["); index >= 0 {
+		name = strings.TrimSpace(segment[:index])
+		rest := segment[index:]
+		for rest != "" {
+			end := -1
+			var quote byte
+			for i := 1; i < len(rest) && end < 0; i++ {
+				switch char := rest[i]; {
+				case quote != 0:
+					if char == quote {
+						quote = 0
+					}
+				case char == '\'' || char == '"':
+					quote = char
+				case char == ']':
+					end = i
+				}
+			}
+			if rest[0] != '[' || end < 0 {
+				invalid("malformed predicate")
+			}
+			predicates = append(predicates, xml_parse_predicate(strings.TrimSpace(rest[1:end]), invalid))
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+	}
+
+	step := xml_step{axis: "child", name: name, predicates: predicates}
+	switch {
+	case name == ".":
+		step.axis = "self"
+	case name == "..":
+		step.axis = "parent"
+	case name == "text()":
+		step.axis = "text"
+	case strings.HasPrefix(name, "@"):
+		step.axis = "attribute"
+		step.name = name[1:]
+	case name == "":
+		invalid("missing element name")
+	}
+	if (step.axis == "attribute" || step.axis == "text") && len(predicates) > 0 {
+		invalid("predicates are not supported on " + name)
+	}
+	return step
+}
+
+func xml_parse_predicate(predicate string, invalid func(string)) xml_predicate {
+	if predicate == "last()" {
+		return xml_predicate{last: true}
+	}
+	if position, err := strconv.Atoi(predicate); err == nil {
+		if position < 1 {
+			invalid("positions start at 1")
+		}
+		return xml_predicate{position: position}
+	}
+
+	result := xml_predicate{target: predicate}
+	for _, operator := range []string{"!=", "="} {
+		target, value, found := strings.Cut(predicate, operator)
+		if !found {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) < 2 || (value[0] != '\'' && value[0] != '"') || value[len(value)-1] != value[0] {
+			invalid("predicate values must be quoted")
+		}
+		result = xml_predicate{target: strings.TrimSpace(target), operator: operator, value: value[1 : len(value)-1]}
+		break
+	}
+
+	if result.target == "" || result.target == "@" {
+		invalid("malformed predicate")
+	}
+	return result
+}
+
+// xml_query_namespaces reads the optional map from prefixes to namespace URIs
+// passed to find and find_all
+func xml_query_namespaces(args []Value) map[string]string {
+	namespaces := make(map[string]string)
+	if len(args) == 0 {
+		return namespaces
+	}
+
+	entries, ok := args[0].(Map)
+	if !ok {
+		panic(fmt.Sprintf("xml namespaces must be a map but got %v", args[0].Type()))
+	}
+	for _, entry := range *entries.entries {
+		namespaces[entry.key.String()] = format_value(entry.value, false)
+	}
+	return namespaces
+}
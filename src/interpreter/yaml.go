@@ -0,0 +1,140 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var yaml_error_line = regexp.MustCompile(`line (\d+)`)
+
+// throw_yaml_error throws a syntax error for a YAML document, with the line
+// of the problem when the parser reports one
+func throw_yaml_error(err error) {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	fields := make([]MapEntry, 0)
+	if match := yaml_error_line.FindStringSubmatch(message); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		fields = append(fields, MapEntry{NewString("line"), NewNumber(float64(line))})
+	}
+	panic(NewThrowError(NewStructuredError("YAML parse error: "+message, ErrorCodeSyntax, nil, fields)))
+}
+
+// yaml_parse_all parses every document of a YAML stream
+func yaml_parse_all(text string) []Value {
+	decoder := yaml.NewDecoder(strings.NewReader(text))
+
+	documents := make([]Value, 0)
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			throw_yaml_error(err)
+		}
+		documents = append(documents, convert_to_value(document))
+	}
+	return documents
+}
+
+// yaml_stringify encodes values as YAML documents separated by "---". Map
+// keys are written in sorted order.
+func yaml_stringify(documents []Value, options Value) string {
+	indent := 2
+	if options != nil {
+		opts, ok := options.(Map)
+		if !ok {
+			panic(fmt.Sprintf("yaml options must be a map but got %v", options.Type()))
+		}
+		for _, entry := range *opts.entries {
+			switch entry.key.String() {
+			case "indent":
+				number, ok := entry.value.(Number)
+				if !ok {
+					panic(fmt.Sprintf("yaml option 'indent' has the wrong type %v", entry.value.Type()))
+				}
+				indent = int(number.Value())
+			default:
+				panic(fmt.Sprintf("unknown yaml option: %s", entry.key.String()))
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(indent)
+	for _, document := range documents {
+		if err := encoder.Encode(native_integers(convert_to_native(document))); err != nil {
+			throw_error(ErrorCodeInvalid, "yaml: %s", err.Error())
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		throw_error(ErrorCodeInvalid, "yaml: %s", err.Error())
+	}
+	return buffer.String()
+}
+
+func init_yaml_module() Module {
+	module := NewModule()
+
+	optional := func(args []Value, index int) Value {
+		if len(args) > index {
+			return args[index]
+		}
+		return nil
+	}
+
+	// parse(yaml: string): any
+	// Purpose: Parses the first document of a YAML string into maps, slices, strings, numbers, booleans and nil
+	module.exports["parse"] = NewNativeFunction(
+		func(args ...Value) Value {
+			documents := yaml_parse_all(args[0].(String).Value())
+			if len(documents) == 0 {
+				return NewNil()
+			}
+			return documents[0]
+		},
+		[]Type{PrimitiveType{StringType}},
+		PrimitiveType{AnyType},
+	)
+
+	// parse_all(yaml: string): []any
+	// Purpose: Parses every document of a YAML stream separated by "---"
+	module.exports["parse_all"] = NewNativeFunction(
+		func(args ...Value) Value {
+			return NewSlice(yaml_parse_all(args[0].(String).Value()), PrimitiveType{AnyType})
+		},
+		[]Type{PrimitiveType{StringType}},
+		NewSliceType(PrimitiveType{AnyType}),
+	)
+
+	// stringify(value: any, options?: map): string
+	// Purpose: Encodes a value as a YAML document
+	module.exports["stringify"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			return NewString(yaml_stringify(args[:1], optional(args, 1)))
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{StringType},
+	)
+
+	// stringify_all(documents: []any, options?: map): string
+	// Purpose: Encodes each value as a YAML document of a single stream
+	module.exports["stringify_all"] = NewVariadicNativeFunction(
+		func(args ...Value) Value {
+			return NewString(yaml_stringify(elements_of(args[0]), optional(args, 1)))
+		},
+		[]Type{PrimitiveType{AnyType}, PrimitiveType{AnyType}},
+		PrimitiveType{StringType},
+	)
+
+	return *module
+}
@@ -78,6 +78,7 @@ func needs_semi_colon(token Token) bool {
 		BREAK,
 		CONTINUE,
 		RETURN,
+		THROW,
 		TRUE,
 		FALSE,
 		NIL,
@@ -2,6 +2,8 @@ package lexer
 
 import (
 	"regexp"
+
+	"github.com/table-harmony/HarmonyLang/src/helpers"
 )
 
 func default_handler(kind TokenKind, value string) regex_handler {
@@ -11,11 +13,14 @@ func default_handler(kind TokenKind, value string) regex_handler {
 	}
 }
 
+// string_handler reads a string literal, its escapes are turned into the
+// characters they stand for once here so that every writer writes strings as
+// they are
 func string_handler(lex *lexer, regex *regexp.Regexp) {
 	match := regex.FindStringIndex(lex.remainder())
 	stringLiteral := lex.remainder()[match[0]+1 : match[1]-1]
 
-	lex.push(NewToken(STRING, stringLiteral))
+	lex.push(NewToken(STRING, helpers.ProcessEscapes(stringLiteral)))
 	lex.advance(len(stringLiteral) + 2)
 }
 
@@ -61,7 +66,7 @@ var reserved_patterns = []regex_pattern{
 	{regexp.MustCompile(`\r\n|\r|\n`), newline_handler},
 	{regexp.MustCompile(`[ \t]+`), skip_handler},
 	{regexp.MustCompile(`\/\/.*`), comment_handler},
-	{regexp.MustCompile(`"[^"]*"`), string_handler},
+	{regexp.MustCompile(`"(?:[^"\\]|\\[\s\S])*"`), string_handler},
 	{regexp.MustCompile(`[0-9]+(\.[0-9]+)?`), number_handler},
 	{regexp.MustCompile(`([a-zA-Z_]|[\x{1F600}-\x{1F64F}\x{2700}-\x{27BF}\x{1F680}-\x{1F6FF}\x{1F300}-\x{1F5FF}\x{1F900}-\x{1F9FF}\x{2600}-\x{26FF}\x{2300}-\x{23FF}\x{1F100}-\x{1F1FF}\x{1F200}-\x{1F2FF}\x{3297}\x{3299}\x{1F191}-\x{1F19A}\x{1F170}-\x{1F19A}])([a-zA-Z0-9_]|[\x{1F600}-\x{1F64F}\x{2700}-\x{27BF}\x{1F680}-\x{1F6FF}\x{1F300}-\x{1F5FF}\x{1F900}-\x{1F9FF}\x{2600}-\x{26FF}\x{2300}-\x{23FF}\x{1F100}-\x{1F1FF}\x{1F200}-\x{1F2FF}\x{3297}\x{3299}\x{1F191}-\x{1F19A}\x{1F170}-\x{1F19A}])*`), symbol_handler},
 
@@ -99,6 +104,7 @@ var reserved_patterns = []regex_pattern{
 	{regexp.MustCompile(`,`), default_handler(COMMA, ",")},
 	{regexp.MustCompile(`->`), default_handler(ARROW, "->")},
 	{regexp.MustCompile(`&`), default_handler(AMPERSAND, "&")},
+	{regexp.MustCompile(`@`), default_handler(AT, "@")},
 
 	// Shorthand
 	{regexp.MustCompile(`\+\+`), default_handler(PLUS_PLUS, "++")},
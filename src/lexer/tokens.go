@@ -46,6 +46,7 @@ const (
 	COMMA
 	ARROW
 	AMPERSAND
+	AT
 
 	// Shorthand
 	PLUS_PLUS
@@ -70,6 +71,7 @@ const (
 	LET
 	CONST
 	IMPORT
+	EXPORT
 	FROM
 	FN
 	STRUCT
@@ -89,7 +91,9 @@ const (
 	MAP
 	TRY
 	CATCH
+	FINALLY
 	THROW
+	DEFER
 	TYPEOF
 	TYPE
 	AS
@@ -103,6 +107,7 @@ var reserved_keywords map[string]TokenKind = map[string]TokenKind{
 	"let":       LET,
 	"const":     CONST,
 	"import":    IMPORT,
+	"export":    EXPORT,
 	"from":      FROM,
 	"fn":        FN,
 	"if":        IF,
@@ -122,7 +127,9 @@ var reserved_keywords map[string]TokenKind = map[string]TokenKind{
 	"map":       MAP,
 	"try":       TRY,
 	"catch":     CATCH,
+	"finally":   FINALLY,
 	"throw":     THROW,
+	"defer":     DEFER,
 	"typeof":    TYPEOF,
 	"type":      TYPE,
 	"as":        AS,
@@ -160,6 +167,11 @@ func (token Token) IsOfKind(expectedTokens ...TokenKind) bool {
 	return false
 }
 
+func (token Token) IsKeyword() bool {
+	kind, found := reserved_keywords[token.Value]
+	return found && kind == token.Kind
+}
+
 func (kind TokenKind) String() string {
 	switch kind {
 	case EOF:
@@ -256,6 +268,8 @@ func (kind TokenKind) String() string {
 		return "const"
 	case IMPORT:
 		return "import"
+	case EXPORT:
+		return "export"
 	case FROM:
 		return "from"
 	case FN:
@@ -292,14 +306,20 @@ func (kind TokenKind) String() string {
 		return "break"
 	case AMPERSAND:
 		return "ampersand"
+	case AT:
+		return "at"
 	case MAP:
 		return "map"
 	case TRY:
 		return "try"
 	case CATCH:
 		return "catch"
+	case FINALLY:
+		return "finally"
 	case THROW:
 		return "throw"
+	case DEFER:
+		return "defer"
 	case TYPEOF:
 		return "typeof"
 	case TYPE:
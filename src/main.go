@@ -2,38 +2,222 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"time"
 
 	"github.com/table-harmony/HarmonyLang/src/interpreter"
-	"github.com/table-harmony/HarmonyLang/src/lexer"
-	"github.com/table-harmony/HarmonyLang/src/parser"
 )
 
+const version = "0.1.0"
+
+const usage = `usage: harmony [flags] [file | -] [-- args]
+       harmony <command> [arguments]
+
+commands:
+  run <file | -> [-- args]   run a program, - reads it from stdin
+  repl                       start the interactive shell
+  check <paths>              report syntax errors without running anything
+  fmt [-check] <paths>       indent and tidy the whitespace of source files
+  test [paths]               run the test_ functions of *_test.harmony files
+  mod <command>              manage dependencies, run harmony mod for details
+
+flags:
+  -e <code>                  run code given on the command line and print its result
+  --time                     print how long the program took to stderr
+  --version                  print the version
+
+Without a file the program is read from stdin, or the REPL starts when stdin
+is a terminal.
+`
+
+// options are the flags shared by every way of running a program
+type options struct {
+	time bool
+	eval *string
+}
+
+// merge combines the flags given before a subcommand with those after it
+func (o options) merge(other options) options {
+	o.time = o.time || other.time
+	if other.eval != nil {
+		o.eval = other.eval
+	}
+	return o
+}
+
 func main() {
-	start := time.Now()
-	if len(os.Args) == 1 {
-		run_repl()
-	} else {
-		run(os.Args[1])
+	os.Exit(run_cli(os.Args[1:]))
+}
+
+// run_cli runs the command line and returns the exit code: 0 on success, 1
+// when the program fails or checks do not pass and 2 on usage errors
+func run_cli(args []string) int {
+	opts, args, code := parse_flags(args)
+	if code >= 0 {
+		return code
 	}
 
-	duration := time.Since(start)
-	fmt.Printf("Duration: %v\n", duration)
+	if opts.eval != nil {
+		return timed(opts, func() int { return run_eval(*opts.eval, args) })
+	}
+
+	if len(args) == 0 {
+		if stdin_is_terminal() {
+			interpreter.StartREPL()
+			return 0
+		}
+		return timed(opts, func() int { return run_file("-", nil) })
+	}
+
+	switch args[0] {
+	case "run":
+		runOpts, args, code := parse_flags(args[1:])
+		if code >= 0 {
+			return code
+		}
+		opts = opts.merge(runOpts)
+		if opts.eval != nil {
+			return timed(opts, func() int { return run_eval(*opts.eval, args) })
+		}
+		if len(args) == 0 {
+			fmt.Fprint(os.Stderr, "harmony run: expected a file, - reads the program from stdin\n")
+			return 2
+		}
+		return timed(opts, func() int { return run_file(args[0], script_args(args[1:])) })
+	case "repl":
+		interpreter.StartREPL()
+		return 0
+	case "check":
+		return run_check(args[1:])
+	case "fmt":
+		return run_fmt(args[1:])
+	case "test":
+		return timed(opts, func() int { return run_test(args[1:]) })
+	case "mod":
+		return run_mod(args[1:])
+	case "help":
+		fmt.Print(usage)
+		return 0
+	}
+
+	return timed(opts, func() int { return run_file(args[0], script_args(args[1:])) })
+}
+
+// parse_flags reads the flags before the first argument. The exit code is
+// negative unless the command line is done, after --version or an error.
+func parse_flags(args []string) (options, []string, int) {
+	opts := options{}
+	for len(args) > 0 {
+		switch args[0] {
+		case "--time", "-time":
+			opts.time = true
+		case "--version", "-version", "-v":
+			fmt.Printf("harmony %s\n", version)
+			return opts, nil, 0
+		case "--help", "-help", "-h":
+			fmt.Print(usage)
+			return opts, nil, 0
+		case "-e", "--eval":
+			if len(args) < 2 {
+				fmt.Fprintf(os.Stderr, "harmony: %s expects code to run\n", args[0])
+				return opts, nil, 2
+			}
+			opts.eval = &args[1]
+			args = args[1:]
+		default:
+			if len(args[0]) > 1 && args[0][0] == '-' && args[0] != "--" {
+				fmt.Fprintf(os.Stderr, "harmony: unknown flag %s\n\n%s", args[0], usage)
+				return opts, nil, 2
+			}
+			return opts, args, -1
+		}
+		args = args[1:]
+	}
+	return opts, args, -1
+}
+
+// script_args returns the arguments passed on to the program, the ones after
+// an optional --
+func script_args(args []string) []string {
+	if len(args) > 0 && args[0] == "--" {
+		return args[1:]
+	}
+	return args
 }
 
-func run(path string) {
+// timed runs a command and reports its duration on stderr when asked to
+func timed(opts options, command func() int) int {
+	start := time.Now()
+	code := command()
+	if opts.time {
+		fmt.Fprintf(os.Stderr, "Duration: %v\n", time.Since(start))
+	}
+	return code
+}
+
+// read_source reads a source file, - is stdin
+func read_source(path string) (string, error) {
+	if path == "-" {
+		bytes, err := io.ReadAll(os.Stdin)
+		return string(bytes), err
+	}
+
 	bytes, err := os.ReadFile(path)
+	return string(bytes), err
+}
+
+func run_file(path string, args []string) int {
+	source, err := read_source(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "harmony: %v\n", err)
+		return 1
+	}
+
+	program, err := interpreter.Parse(source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", display_name(path), err)
+		return 1
+	}
+
+	if path == "-" {
+		path = ""
+	}
+	interpreter.SetArgs(args)
+	if err := interpreter.Run(program, path); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func run_eval(code string, args []string) int {
+	program, err := interpreter.Parse(code)
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "-e: %v\n", err)
+		return 1
 	}
 
-	source := string(bytes)
-	tokens := lexer.Tokenize(source)
-	ast := parser.Parse(tokens)
-	interpreter.Interpret(ast)
+	interpreter.SetArgs(script_args(args))
+	result, err := interpreter.Evaluate(program, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	if result != "" {
+		fmt.Println(result)
+	}
+	return 0
+}
+
+func display_name(path string) string {
+	if path == "-" {
+		return "<stdin>"
+	}
+	return path
 }
 
-func run_repl() {
-	interpreter.StartREPL()
+func stdin_is_terminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
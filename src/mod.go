@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/table-harmony/HarmonyLang/src/packages"
+)
+
+const mod_usage = `usage: harmony mod <command> [arguments]
+
+commands:
+  init [name]                          create harmony.toml in the current directory
+  add [-name name] <dir>               depend on a package in a local directory
+  add [-name name] [-rev rev] -git <repo>
+                                       depend on a git checkout on disk at a revision
+  tidy                                 remove dependencies no file imports and vendor the rest
+  vendor [-update]                     copy the dependencies into vendor/ and write harmony.lock,
+                                       -update moves git dependencies without a rev to their
+                                       current revision and accepts changed files
+`
+
+// run_mod runs a harmony mod command and returns the exit code
+func run_mod(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, mod_usage)
+		return 2
+	}
+
+	if err := mod_command(args[0], args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "harmony mod %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func mod_command(command string, args []string) error {
+	switch command {
+	case "init":
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if err := packages.Init(".", name); err != nil {
+			return err
+		}
+		fmt.Printf("created %s\n", packages.ManifestFile)
+		return nil
+
+	case "add":
+		flags := flag.NewFlagSet("add", flag.ContinueOnError)
+		name := flags.String("name", "", "name to import the package by")
+		git := flags.Bool("git", false, "the source is a git checkout")
+		rev := flags.String("rev", "", "git revision to vendor, HEAD by default")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("expected the directory of the package after the flags")
+		}
+
+		dependency := packages.Dependency{Path: flags.Arg(0)}
+		if *git {
+			dependency = packages.Dependency{Git: flags.Arg(0), Rev: *rev}
+		} else if *rev != "" {
+			return fmt.Errorf("-rev requires -git")
+		}
+
+		root, err := packages.FindRoot(".")
+		if err != nil {
+			return err
+		}
+		added, changes, err := packages.Add(root, *name, dependency)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("added %s\n", added)
+		print_changes(changes)
+		return nil
+
+	case "tidy":
+		root, err := packages.FindRoot(".")
+		if err != nil {
+			return err
+		}
+		missing, changes, err := packages.Tidy(root)
+		if err != nil {
+			return err
+		}
+		for _, spec := range missing {
+			fmt.Fprintf(os.Stderr, "warning: no dependency provides \"%s\"\n", spec)
+		}
+		print_changes(changes)
+		return nil
+
+	case "vendor":
+		flags := flag.NewFlagSet("vendor", flag.ContinueOnError)
+		update := flags.Bool("update", false, "re-resolve git dependencies and accept changed files")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+
+		root, err := packages.FindRoot(".")
+		if err != nil {
+			return err
+		}
+		changes, err := packages.Vendor(root, *update)
+		if err != nil {
+			return err
+		}
+		print_changes(changes)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown command, run 'harmony mod' for usage")
+	}
+}
+
+func print_changes(changes []packages.Change) {
+	for _, change := range changes {
+		fmt.Printf("%s %s %s\n", change.Action, change.Name, change.Hash)
+	}
+}
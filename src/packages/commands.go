@@ -0,0 +1,221 @@
+package packages
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/table-harmony/HarmonyLang/src/lexer"
+)
+
+// Init creates the manifest of a new package in dir, named after the
+// directory unless a name is given
+func Init(dir string, name string) error {
+	path := filepath.Join(dir, ManifestFile)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	if name == "" {
+		absolute, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		name = filepath.Base(absolute)
+	}
+	return NewManifest(name).Write(dir)
+}
+
+// Add declares a dependency of the project at root and vendors it. The
+// name defaults to the one in the dependency's own manifest, then to the
+// name of its directory. Relative sources are given from the working
+// directory and stored relative to root.
+func Add(root string, name string, dependency Dependency) (string, []Change, error) {
+	manifest, err := ReadManifest(root)
+	if err != nil {
+		return "", nil, err
+	}
+
+	relative := func(path string) (string, error) {
+		if path == "" || filepath.IsAbs(path) {
+			return path, nil
+		}
+		absolute, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Rel(root, absolute)
+	}
+	if dependency.Path, err = relative(dependency.Path); err != nil {
+		return "", nil, err
+	}
+	if dependency.Git, err = relative(dependency.Git); err != nil {
+		return "", nil, err
+	}
+	label := name
+	if label == "" {
+		label = dependency.Path + dependency.Git
+	}
+	if err := dependency.validate(label); err != nil {
+		return "", nil, err
+	}
+
+	pkg, err := load(label, dependency, root)
+	if err != nil {
+		return "", nil, err
+	}
+	if name == "" {
+		name = pkg.manifest.Package.Name
+		if name == "" {
+			name = filepath.Base(pkg.dir)
+		}
+	}
+	if err := validate_name(name); err != nil {
+		return "", nil, err
+	}
+	if name == manifest.Package.Name {
+		return "", nil, fmt.Errorf("package '%s' cannot depend on itself", name)
+	}
+
+	// The manifest is only changed when the new dependency tree resolves
+	manifest.Dependencies[name] = dependency
+	lock, err := ReadLock(root)
+	if err != nil {
+		return "", nil, err
+	}
+	resolved, err := resolve(root, manifest, lock)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := lock.verify(root, resolved); err != nil {
+		return "", nil, err
+	}
+	if err := manifest.Write(root); err != nil {
+		return "", nil, err
+	}
+
+	changes, err := Vendor(root, false)
+	return name, changes, err
+}
+
+// Tidy removes the dependencies no source file of the project imports, then
+// vendors the rest. It also returns the imports that look like packages but
+// match no dependency.
+func Tidy(root string) ([]string, []Change, error) {
+	manifest, err := ReadManifest(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	imports, err := project_imports(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	used := make(map[string]bool)
+	missing := make([]string, 0)
+	for _, spec := range imports {
+		name, found := manifest.provider(spec)
+		if found {
+			used[name] = true
+		} else if strings.Contains(spec, "/") && !local_import(spec) {
+			missing = append(missing, spec)
+		}
+	}
+
+	for name := range manifest.Dependencies {
+		if !used[name] {
+			delete(manifest.Dependencies, name)
+		}
+	}
+	if err := manifest.Write(root); err != nil {
+		return nil, nil, err
+	}
+
+	changes, err := Vendor(root, false)
+	return missing, changes, err
+}
+
+// provider returns the dependency an import refers to, the longest name the
+// import starts with
+func (m *Manifest) provider(spec string) (string, bool) {
+	best := ""
+	for name := range m.Dependencies {
+		if (spec == name || strings.HasPrefix(spec, name+"/")) && len(name) > len(best) {
+			best = name
+		}
+	}
+	return best, best != ""
+}
+
+func local_import(spec string) bool {
+	return strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || filepath.IsAbs(spec)
+}
+
+// project_imports lists the modules imported by the source files of the
+// project, leaving out the vendor tree and hidden directories
+func project_imports(root string) ([]string, error) {
+	seen := make(map[string]bool)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && skipped_dir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".harmony" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		specs, err := file_imports(string(data))
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		for _, spec := range specs {
+			seen[spec] = true
+		}
+		return nil
+	})
+
+	imports := make([]string, 0, len(seen))
+	for spec := range seen {
+		imports = append(imports, spec)
+	}
+	sort.Strings(imports)
+	return imports, err
+}
+
+// file_imports returns the module of every import statement in a source
+// file, the string following the from keyword
+func file_imports(source string) (specs []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	tokens := lexer.Tokenize(source)
+	importing := false
+	for i, token := range tokens {
+		switch token.Kind {
+		case lexer.IMPORT:
+			importing = true
+		case lexer.FROM:
+			if importing && i+1 < len(tokens) && tokens[i+1].Kind == lexer.STRING {
+				specs = append(specs, tokens[i+1].Value)
+			}
+			importing = false
+		}
+	}
+	return specs, nil
+}
@@ -0,0 +1,211 @@
+package packages
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	ManifestFile = "harmony.toml"
+	LockFile     = "harmony.lock"
+	VendorDir    = "vendor"
+
+	// DefaultEntry is the file imported when a package is imported by its
+	// name and its manifest does not name an entry
+	DefaultEntry = "lib.harmony"
+)
+
+// Manifest is the harmony.toml file at the root of a package
+type Manifest struct {
+	Package      Package               `toml:"package"`
+	Dependencies map[string]Dependency `toml:"dependencies"`
+}
+
+type Package struct {
+	Name    string `toml:"name"`
+	Version string `toml:"version"`
+	Entry   string `toml:"entry,omitempty"`
+}
+
+// Dependency is a package copied into the vendor tree, either from a local
+// directory or from a git checkout on disk at a revision. Relative paths are
+// relative to the directory of the manifest declaring the dependency.
+type Dependency struct {
+	Path string `toml:"path,omitempty"`
+	Git  string `toml:"git,omitempty"`
+	Rev  string `toml:"rev,omitempty"`
+}
+
+func (d Dependency) validate(name string) error {
+	switch {
+	case d.Path != "" && d.Git != "":
+		return fmt.Errorf("dependency '%s' cannot have both a path and a git source", name)
+	case d.Path == "" && d.Git == "":
+		return fmt.Errorf("dependency '%s' needs a path or a git source", name)
+	case d.Path != "" && d.Rev != "":
+		return fmt.Errorf("dependency '%s' has a rev but no git source", name)
+	}
+	return nil
+}
+
+// validate_name checks that a dependency name is a relative slash separated
+// path, since it names the directory the dependency is vendored into
+func validate_name(name string) error {
+	if name == "" {
+		return fmt.Errorf("dependency name cannot be empty")
+	}
+	if strings.HasPrefix(name, "/") || strings.Contains(name, "\\") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return fmt.Errorf("dependency name '%s' must be a relative path", name)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("dependency name '%s' cannot have empty, . or .. segments", name)
+		}
+	}
+	return nil
+}
+
+func NewManifest(name string) *Manifest {
+	return &Manifest{
+		Package:      Package{Name: name, Version: "0.1.0"},
+		Dependencies: make(map[string]Dependency),
+	}
+}
+
+// ParseManifest reads a manifest from its contents, file is used in errors
+func ParseManifest(data []byte, file string) (*Manifest, error) {
+	manifest := NewManifest("")
+	manifest.Package.Version = ""
+	if _, err := toml.Decode(string(data), manifest); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	if manifest.Package.Name == "" {
+		return nil, fmt.Errorf("%s: package name is missing", file)
+	}
+	if manifest.Dependencies == nil {
+		manifest.Dependencies = make(map[string]Dependency)
+	}
+	for name, dependency := range manifest.Dependencies {
+		if err := validate_name(name); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		if err := dependency.validate(name); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+	}
+	return manifest, nil
+}
+
+// ReadManifest reads the manifest of the package in the given directory
+func ReadManifest(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, ManifestFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(data, path)
+}
+
+// Write saves the manifest in the given directory
+func (m *Manifest) Write(dir string) error {
+	var buffer bytes.Buffer
+	encoder := toml.NewEncoder(&buffer)
+	encoder.Indent = ""
+
+	if err := encoder.Encode(struct {
+		Package Package `toml:"package"`
+	}{m.Package}); err != nil {
+		return err
+	}
+
+	buffer.WriteString("\n[dependencies]\n")
+	for _, name := range m.names() {
+		dependency := m.Dependencies[name]
+		fmt.Fprintf(&buffer, "%s = { ", toml_key(name))
+		if dependency.Path != "" {
+			fmt.Fprintf(&buffer, "path = %q }\n", filepath.ToSlash(dependency.Path))
+		} else if dependency.Rev != "" {
+			fmt.Fprintf(&buffer, "git = %q, rev = %q }\n", filepath.ToSlash(dependency.Git), dependency.Rev)
+		} else {
+			fmt.Fprintf(&buffer, "git = %q }\n", filepath.ToSlash(dependency.Git))
+		}
+	}
+
+	return os.WriteFile(filepath.Join(dir, ManifestFile), buffer.Bytes(), 0644)
+}
+
+// EntryFile returns the path of the file imported for the package itself
+func (m *Manifest) EntryFile() string {
+	if m.Package.Entry != "" {
+		return filepath.FromSlash(m.Package.Entry)
+	}
+	return DefaultEntry
+}
+
+// PackageEntry returns the file a directory is imported through when it is
+// a package: the entry of its manifest, or the default entry of a directory
+// without one, the way such directories are vendored
+func PackageEntry(dir string) (string, bool) {
+	if manifest, err := ReadManifest(dir); err == nil {
+		return filepath.Join(dir, manifest.EntryFile()), true
+	} else if !os.IsNotExist(err) {
+		return "", false
+	}
+
+	entry := filepath.Join(dir, DefaultEntry)
+	if info, err := os.Stat(entry); err == nil && !info.IsDir() {
+		return entry, true
+	}
+	return "", false
+}
+
+func (m *Manifest) names() []string {
+	names := make([]string, 0, len(m.Dependencies))
+	for name := range m.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FindRoot returns the closest directory from dir upwards containing a
+// manifest
+func FindRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("no harmony.toml found in this directory or any parent, run 'harmony mod init' first")
+		}
+		dir = parent
+	}
+}
+
+// toml_key quotes keys that are not bare TOML keys, like package names with
+// a slash
+func toml_key(key string) string {
+	for _, char := range key {
+		bare := char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' ||
+			char >= '0' && char <= '9' || char == '_' || char == '-'
+		if !bare {
+			return fmt.Sprintf("%q", key)
+		}
+	}
+	return key
+}
@@ -0,0 +1,207 @@
+package packages
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// resolved is a dependency read from its source, with the files that go
+// into the vendor tree
+type resolved struct {
+	name     string
+	kind     string
+	commit   string
+	manifest *Manifest
+	files    map[string][]byte
+
+	// dir is where relative dependencies of the package are resolved from
+	dir string
+}
+
+// load reads a dependency relative to the directory of the manifest that
+// declares it
+func load(name string, dependency Dependency, base string) (*resolved, error) {
+	if dependency.Path != "" {
+		return load_path(name, join(base, dependency.Path))
+	}
+	return load_git(name, join(base, dependency.Git), dependency.Rev)
+}
+
+func load_path(name string, dir string) (*resolved, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("dependency '%s': %v", name, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("dependency '%s': %s is not a directory", name, dir)
+	}
+
+	files := make(map[string][]byte)
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && skipped_dir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if relative == LockFile {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relative)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dependency '%s': %v", name, err)
+	}
+
+	return new_resolved(name, "path", "", files, dir)
+}
+
+// load_git reads the files of a git checkout at a revision, HEAD by default.
+// Only repositories already on disk are used, nothing is fetched.
+func load_git(name string, repository string, rev string) (*resolved, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	git := func(args ...string) ([]byte, error) {
+		var stderr bytes.Buffer
+		command := exec.Command("git", append([]string{"-C", repository}, args...)...)
+		command.Stderr = &stderr
+		output, err := command.Output()
+		if err != nil {
+			message := strings.TrimSpace(stderr.String())
+			if message == "" {
+				message = err.Error()
+			}
+			return nil, fmt.Errorf("dependency '%s': git %s: %s", name, args[0], message)
+		}
+		return output, nil
+	}
+
+	output, err := git("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("dependency '%s': unknown revision '%s' in %s", name, rev, repository)
+	}
+	commit := strings.TrimSpace(string(output))
+
+	output, err = git("ls-tree", "-r", "-z", "--name-only", commit)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, path := range strings.Split(strings.TrimRight(string(output), "\x00"), "\x00") {
+		if path == "" || path == LockFile || skipped_path(path) {
+			continue
+		}
+		data, err := git("cat-file", "blob", commit+":"+path)
+		if err != nil {
+			return nil, err
+		}
+		files[path] = data
+	}
+
+	return new_resolved(name, "git", commit, files, repository)
+}
+
+func new_resolved(name string, kind string, commit string, files map[string][]byte, dir string) (*resolved, error) {
+	// A directory without a manifest is a package without dependencies
+	manifest := NewManifest("")
+	manifest.Package.Version = ""
+	if data, exists := files[ManifestFile]; exists {
+		var err error
+		manifest, err = ParseManifest(data, filepath.Join(dir, ManifestFile))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &resolved{
+		name:     name,
+		kind:     kind,
+		commit:   commit,
+		manifest: manifest,
+		files:    files,
+		dir:      dir,
+	}, nil
+}
+
+// source describes where the package comes from, relative to the root of
+// the project so that lockfiles can be shared
+func (r *resolved) source(root string) string {
+	source := r.kind + "+" + source_dir(root, r.dir)
+	if r.commit != "" {
+		source += "#" + r.commit
+	}
+	return source
+}
+
+// source_dir is a directory as the sources of the lockfile give it
+func source_dir(root string, dir string) string {
+	if relative, err := filepath.Rel(root, dir); err == nil {
+		dir = relative
+	}
+	return filepath.ToSlash(dir)
+}
+
+// hash is a digest of the paths and contents of the package files
+func (r *resolved) hash() string {
+	paths := make([]string, 0, len(r.files))
+	for path := range r.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	digest := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(digest, "%s\x00%d\x00", path, len(r.files[path]))
+		digest.Write(r.files[path])
+	}
+	return "sha256:" + hex.EncodeToString(digest.Sum(nil))
+}
+
+// skipped_dir reports directories that are never copied into the vendor
+// tree: version control data, hidden directories and nested vendor trees
+func skipped_dir(name string) bool {
+	return name == VendorDir || strings.HasPrefix(name, ".")
+}
+
+func skipped_path(path string) bool {
+	parts := strings.Split(path, "/")
+	for _, part := range parts[:len(parts)-1] {
+		if skipped_dir(part) {
+			return true
+		}
+	}
+	return false
+}
+
+func join(base string, path string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(base, path)
+}
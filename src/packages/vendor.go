@@ -0,0 +1,232 @@
+package packages
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Lock is the harmony.lock file recording the exact packages in the vendor
+// tree, direct and transitive, with a hash of their contents
+type Lock struct {
+	Packages []LockedPackage `toml:"package"`
+}
+
+type LockedPackage struct {
+	Name         string   `toml:"name"`
+	Version      string   `toml:"version,omitempty"`
+	Source       string   `toml:"source"`
+	Hash         string   `toml:"hash"`
+	Dependencies []string `toml:"dependencies,omitempty"`
+}
+
+// ReadLock reads the lockfile of the project in the given directory, an
+// empty lock when there is none
+func ReadLock(dir string) (*Lock, error) {
+	path := filepath.Join(dir, LockFile)
+	lock := &Lock{}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return lock, nil
+	}
+	if _, err := toml.DecodeFile(path, lock); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return lock, nil
+}
+
+func (l *Lock) Write(dir string) error {
+	var buffer bytes.Buffer
+	buffer.WriteString("# This file is generated by harmony mod, do not edit it by hand\n\n")
+
+	encoder := toml.NewEncoder(&buffer)
+	encoder.Indent = ""
+	if err := encoder.Encode(l); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, LockFile), buffer.Bytes(), 0644)
+}
+
+func (l *Lock) find(name string) (LockedPackage, bool) {
+	for _, locked := range l.Packages {
+		if locked.Name == name {
+			return locked, true
+		}
+	}
+	return LockedPackage{}, false
+}
+
+// pinned returns the dependency to load for a package. A git dependency
+// without a rev is loaded at the commit the lock records for the same
+// repository, so vendoring again gives the same files.
+func (l *Lock) pinned(root string, name string, dependency Dependency, base string) Dependency {
+	if l == nil || dependency.Git == "" || dependency.Rev != "" {
+		return dependency
+	}
+	locked, exists := l.find(name)
+	if !exists {
+		return dependency
+	}
+
+	prefix := "git+" + source_dir(root, join(base, dependency.Git)) + "#"
+	if commit, ok := strings.CutPrefix(locked.Source, prefix); ok {
+		dependency.Rev = commit
+	}
+	return dependency
+}
+
+// resolve loads the dependencies of the project and of its dependencies,
+// git dependencies without a rev at the commits pinned by the lock unless it
+// is nil. Every package appears once, two packages requiring the same name
+// from different sources is an error.
+func resolve(root string, manifest *Manifest, lock *Lock) ([]*resolved, error) {
+	packages := make(map[string]*resolved)
+	required := make(map[string]string)
+
+	var visit func(manifest *Manifest, base string, requiredBy string) error
+	visit = func(manifest *Manifest, base string, requiredBy string) error {
+		for _, name := range manifest.names() {
+			pkg, err := load(name, lock.pinned(root, name, manifest.Dependencies[name], base), base)
+			if err != nil {
+				return err
+			}
+
+			if existing, exists := packages[name]; exists {
+				if existing.source(root) != pkg.source(root) {
+					return fmt.Errorf("dependency '%s' is required from %s by %s and from %s by %s",
+						name, existing.source(root), required[name], pkg.source(root), requiredBy)
+				}
+				continue
+			}
+
+			packages[name] = pkg
+			required[name] = requiredBy
+			if err := visit(pkg.manifest, pkg.dir, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := visit(manifest, root, manifest.Package.Name); err != nil {
+		return nil, err
+	}
+
+	result := make([]*resolved, 0, len(packages))
+	for _, pkg := range packages {
+		result = append(result, pkg)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result, nil
+}
+
+// vendor_target returns where a file of a package is written, refusing paths
+// that would land outside of the vendor tree
+func vendor_target(vendor string, name string, path string) (string, error) {
+	target := filepath.Join(vendor, filepath.FromSlash(name), filepath.FromSlash(path))
+	rel, err := filepath.Rel(vendor, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("package '%s' file '%s' is outside of the vendor tree", name, path)
+	}
+	return target, nil
+}
+
+// verify checks that the packages the lock records from the same source
+// still have the files it hashed
+func (l *Lock) verify(root string, packages []*resolved) error {
+	for _, pkg := range packages {
+		locked, exists := l.find(pkg.name)
+		if !exists || locked.Source != pkg.source(root) {
+			continue
+		}
+		if hash := pkg.hash(); hash != locked.Hash {
+			return fmt.Errorf("dependency '%s' does not match %s: its files hash to %s but %s is locked, run harmony mod vendor -update to accept the change",
+				pkg.name, LockFile, hash, locked.Hash)
+		}
+	}
+	return nil
+}
+
+// Change is a package added, updated or removed by a vendor run
+type Change struct {
+	Name   string
+	Action string
+	Hash   string
+}
+
+// Vendor copies the dependencies of the project at root into its vendor
+// tree, replacing what was there, and rewrites the lockfile. Unless update
+// is set, git dependencies without a rev stay at their locked commits and a
+// package whose files no longer match the hash in the lock is an error.
+func Vendor(root string, update bool) ([]Change, error) {
+	manifest, err := ReadManifest(root)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := ReadLock(root)
+	if err != nil {
+		return nil, err
+	}
+
+	pins := previous
+	if update {
+		pins = nil
+	}
+	packages, err := resolve(root, manifest, pins)
+	if err != nil {
+		return nil, err
+	}
+	if !update {
+		if err := previous.verify(root, packages); err != nil {
+			return nil, err
+		}
+	}
+
+	vendor := filepath.Join(root, VendorDir)
+	if err := os.RemoveAll(vendor); err != nil {
+		return nil, err
+	}
+
+	lock := &Lock{Packages: make([]LockedPackage, 0, len(packages))}
+	changes := make([]Change, 0)
+	for _, pkg := range packages {
+		for path, data := range pkg.files {
+			target, err := vendor_target(vendor, pkg.name, path)
+			if err != nil {
+				return nil, err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(target, data, 0644); err != nil {
+				return nil, err
+			}
+		}
+
+		locked := LockedPackage{
+			Name:         pkg.name,
+			Version:      pkg.manifest.Package.Version,
+			Source:       pkg.source(root),
+			Hash:         pkg.hash(),
+			Dependencies: pkg.manifest.names(),
+		}
+		lock.Packages = append(lock.Packages, locked)
+
+		if old, exists := previous.find(pkg.name); !exists {
+			changes = append(changes, Change{pkg.name, "added", locked.Hash})
+		} else if old.Hash != locked.Hash || old.Source != locked.Source {
+			changes = append(changes, Change{pkg.name, "updated", locked.Hash})
+		}
+	}
+	for _, old := range previous.Packages {
+		if _, exists := lock.find(old.Name); !exists {
+			changes = append(changes, Change{old.Name, "removed", old.Hash})
+		}
+	}
+
+	return changes, lock.Write(root)
+}
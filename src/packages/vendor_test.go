@@ -0,0 +1,183 @@
+package packages
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write_file(t *testing.T, path string, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseManifestRejectsUnsafeNames(t *testing.T) {
+	for _, name := range []string{"", ".", "..", "../../escaped", "a/../b", "a//b", "/abs", "a/", `a\b`} {
+		data := "[package]\nname = \"app\"\n\n[dependencies]\n" + toml_key(name) + " = { path = \"../dep\" }\n"
+		if _, err := ParseManifest([]byte(data), ManifestFile); err == nil {
+			t.Errorf("dependency name %q was accepted", name)
+		}
+	}
+
+	data := "[package]\nname = \"app\"\n\n[dependencies]\n\"org/utils\" = { path = \"../dep\" }\n"
+	if _, err := ParseManifest([]byte(data), ManifestFile); err != nil {
+		t.Errorf("nested dependency name was rejected: %v", err)
+	}
+}
+
+func TestAddRejectsEscapingTransitiveDependency(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project")
+	write_file(t, filepath.Join(project, ManifestFile), "[package]\nname = \"project\"\n")
+	write_file(t, filepath.Join(dir, "utils", ManifestFile),
+		"[package]\nname = \"utils\"\n\n[dependencies]\n\"../../escaped\" = { path = \"../evil\" }\n")
+	write_file(t, filepath.Join(dir, "utils", DefaultEntry), "")
+	write_file(t, filepath.Join(dir, "evil", ManifestFile), "[package]\nname = \"evil\"\n")
+	write_file(t, filepath.Join(dir, "evil", "x.txt"), "owned")
+
+	if _, _, err := Add(project, "", Dependency{Path: filepath.Join(dir, "utils")}); err == nil {
+		t.Fatal("expected the escaping dependency name to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped")); !os.IsNotExist(err) {
+		t.Fatalf("a file was written outside of the vendor tree: %v", err)
+	}
+	if _, _, err := Add(project, "..", Dependency{Path: filepath.Join(dir, "evil")}); err == nil {
+		t.Fatal("expected the name .. to be rejected")
+	}
+}
+
+func TestVendorTargetStaysInVendor(t *testing.T) {
+	vendor := filepath.Join(t.TempDir(), VendorDir)
+	if _, err := vendor_target(vendor, "utils", "../../x.txt"); err == nil {
+		t.Error("a file path escaping the package was accepted")
+	}
+	if _, err := vendor_target(vendor, "..", "x.txt"); err == nil {
+		t.Error("a package name escaping the vendor tree was accepted")
+	}
+
+	target, err := vendor_target(vendor, "org/utils", "src/lib.harmony")
+	if err != nil || !strings.HasPrefix(target, vendor+string(filepath.Separator)) {
+		t.Errorf("unexpected target %q, %v", target, err)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	command := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	command.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	output, err := command.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func read_file(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestVendorKeepsGitDependencyAtLockedCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	repository := filepath.Join(dir, "mathx")
+	write_file(t, filepath.Join(repository, DefaultEntry), "export const version = 1\n")
+	git(t, repository, "init", "-q")
+	git(t, repository, "add", ".")
+	git(t, repository, "commit", "-q", "-m", "first")
+
+	project := filepath.Join(dir, "project")
+	write_file(t, filepath.Join(project, ManifestFile),
+		"[package]\nname = \"project\"\n\n[dependencies]\nmathx = { git = \"../mathx\" }\n")
+	if _, err := Vendor(project, false); err != nil {
+		t.Fatal(err)
+	}
+	lock := read_file(t, filepath.Join(project, LockFile))
+
+	write_file(t, filepath.Join(repository, DefaultEntry), "export const version = 2\n")
+	git(t, repository, "commit", "-q", "-am", "second")
+
+	vendored := filepath.Join(project, VendorDir, "mathx", DefaultEntry)
+	changes, err := Vendor(project, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 || read_file(t, filepath.Join(project, LockFile)) != lock {
+		t.Errorf("vendoring again moved the locked commit: %v", changes)
+	}
+	if content := read_file(t, vendored); !strings.Contains(content, "version = 1") {
+		t.Errorf("vendored the new commit without -update: %q", content)
+	}
+
+	changes, err = Vendor(project, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Action != "updated" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+	if content := read_file(t, vendored); !strings.Contains(content, "version = 2") {
+		t.Errorf("-update did not vendor the new commit: %q", content)
+	}
+}
+
+func TestVendorRejectsChangedFilesUnlessUpdating(t *testing.T) {
+	dir := t.TempDir()
+	write_file(t, filepath.Join(dir, "utils", DefaultEntry), "export const a = 1\n")
+	project := filepath.Join(dir, "project")
+	write_file(t, filepath.Join(project, ManifestFile),
+		"[package]\nname = \"project\"\n\n[dependencies]\nutils = { path = \"../utils\" }\n")
+	if _, err := Vendor(project, false); err != nil {
+		t.Fatal(err)
+	}
+
+	write_file(t, filepath.Join(dir, "utils", DefaultEntry), "export const a = 2\n")
+	if _, err := Vendor(project, false); err == nil || !strings.Contains(err.Error(), "-update") {
+		t.Fatalf("expected a hash mismatch, got %v", err)
+	}
+	if content := read_file(t, filepath.Join(project, VendorDir, "utils", DefaultEntry)); content != "export const a = 1\n" {
+		t.Errorf("a failed vendor run changed the vendor tree: %q", content)
+	}
+
+	changes, err := Vendor(project, true)
+	if err != nil || len(changes) != 1 || changes[0].Action != "updated" {
+		t.Errorf("unexpected result of -update: %v, %v", changes, err)
+	}
+}
+
+func TestAddedDirectoryWithoutManifestIsImportedByName(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project")
+	write_file(t, filepath.Join(project, ManifestFile), "[package]\nname = \"project\"\n")
+	write_file(t, filepath.Join(dir, "utils", DefaultEntry), "export const a = 1\n")
+
+	name, _, err := Add(project, "", Dependency{Path: filepath.Join(dir, "utils")})
+	if err != nil || name != "utils" {
+		t.Fatalf("unexpected result %q, %v", name, err)
+	}
+
+	vendored := filepath.Join(project, VendorDir, "utils")
+	entry, ok := PackageEntry(vendored)
+	if !ok || entry != filepath.Join(vendored, DefaultEntry) {
+		t.Errorf("the vendored directory has no entry: %q, %v", entry, ok)
+	}
+	if _, ok := PackageEntry(filepath.Join(project, VendorDir)); ok {
+		t.Error("a directory without a manifest or an entry file was taken for a package")
+	}
+}
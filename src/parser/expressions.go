@@ -142,6 +142,15 @@ func parse_member_expression(parser *parser, left ast.Expression, bp binding_pow
 	parser.expect(lexer.DOT)
 	parser.advance(1)
 
+	// Keywords are valid property names, e.g. errors.as
+	if token := parser.current_token(); token.IsKeyword() {
+		parser.advance(1)
+		return ast.MemberExpression{
+			Owner:    left,
+			Property: ast.SymbolExpression{Value: token.Value},
+		}
+	}
+
 	property, err := ast.ExpectExpression[ast.SymbolExpression](parse_primary_expression(parser))
 	if err != nil {
 		panic(err)
@@ -346,21 +355,56 @@ func parse_try_catch_expression(parser *parser) ast.Expression {
 
 	tryBlock := parse_block_expression(parser)
 
+	catchClauses := make([]ast.CatchClause, 0)
+	for parser.current_token().Kind == lexer.CATCH {
+		catchClauses = append(catchClauses, parse_catch_clause(parser))
+	}
+
+	var finallyBlock ast.Expression
+	if parser.current_token().Kind == lexer.FINALLY {
+		parser.advance(1)
+		finallyBlock = parse_block_expression(parser)
+	}
+
+	if len(catchClauses) == 0 && finallyBlock == nil {
+		panic(fmt.Sprintf("Expected catch or finally after try block at line %d\n", parser.current_token().Line))
+	}
+
+	return ast.TryCatchExpression{
+		TryBlock:     tryBlock,
+		CatchClauses: catchClauses,
+		FinallyBlock: finallyBlock,
+	}
+}
+
+func parse_catch_clause(parser *parser) ast.CatchClause {
 	parser.expect(lexer.CATCH)
 	parser.advance(1)
 
 	var errorIdentifier string
-	if parser.current_token().Kind != lexer.OPEN_CURLY {
+	var errorType ast.Type
+	if parser.current_token().Kind == lexer.OPEN_PAREN {
+		parser.advance(1)
+
 		errorIdentifier = parser.expect(lexer.IDENTIFIER).Value
 		parser.advance(1)
-	}
 
-	catchBlock := parse_block_expression(parser)
+		if parser.current_token().Kind == lexer.COLON {
+			parser.advance(1)
+			errorType = parse_type(parser, default_bp)
+		}
 
-	return ast.TryCatchExpression{
-		TryBlock:        tryBlock,
-		CatchBlock:      catchBlock,
+		parser.expect(lexer.CLOSE_PAREN)
+		parser.advance(1)
+	} else if parser.current_token().Kind != lexer.OPEN_CURLY {
+		errorIdentifier = parser.expect(lexer.IDENTIFIER).Value
+		parser.advance(1)
+	}
+
+	return ast.CatchClause{
 		ErrorIdentifier: errorIdentifier,
+		ErrorType:       errorType,
+		Body:            parse_block_expression(parser),
 	}
 }
 
@@ -509,6 +553,14 @@ func parse_struct_instantiation_expression(parser *parser) ast.Expression {
 
 	constructor := parse_expression(parser, default_bp)
 
+	// new T(...) calls the constructor of T instead of building a literal
+	if call, ok := constructor.(ast.CallExpression); ok && parser.current_token().Kind != lexer.OPEN_CURLY {
+		return ast.ConstructorCallExpression{
+			Constructor: call.Caller,
+			Arguments:   call.Params,
+		}
+	}
+
 	parser.expect(lexer.OPEN_CURLY)
 	parser.advance(1)
 
@@ -136,6 +136,7 @@ func create_token_lookups() {
 	// Statements
 	register_statement(lexer.TYPE, parse_type_declaration_statement)
 	register_statement(lexer.IMPORT, parse_import_statement)
+	register_statement(lexer.EXPORT, parse_export_statement)
 	register_statement(lexer.LET, parse_multi_variable_declaration_statement)
 	register_statement(lexer.CONST, parse_multi_variable_declaration_statement)
 	register_statement(lexer.INTERFACE, parse_interface_declaration_statement)
@@ -146,4 +147,5 @@ func create_token_lookups() {
 	register_statement(lexer.BREAK, parse_loop_control_statement)
 	register_statement(lexer.RETURN, parse_return_statement)
 	register_statement(lexer.THROW, parse_throw_statement)
+	register_statement(lexer.DEFER, parse_defer_statement)
 }
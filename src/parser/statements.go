@@ -175,6 +175,25 @@ func parse_import_statement(parser *parser) ast.Statement {
 	}
 }
 
+func parse_export_statement(parser *parser) ast.Statement {
+	parser.expect(lexer.EXPORT)
+	parser.advance(1)
+
+	token := parser.current_token()
+	if !token.IsOfKind(lexer.LET, lexer.CONST, lexer.FN, lexer.STRUCT) {
+		panic(fmt.Errorf("expected a let, const, fn or struct declaration after export but got %s", token.Kind))
+	}
+
+	declaration := parse_statement(parser)
+	if function, ok := declaration.(ast.FunctionDeclarationStatment); ok && function.Receiver != nil {
+		panic(fmt.Errorf("cannot export method '%s', export the struct instead", function.Identifier))
+	}
+
+	return ast.ExportStatement{
+		Declaration: declaration,
+	}
+}
+
 func parse_named_imports(parser *parser) map[string]string {
 	var namedAlias string
 	namedImports := make(map[string]string)
@@ -212,6 +231,41 @@ func parse_interface_declaration_statement(parser *parser) ast.Statement {
 	panic("Not implemented yet")
 }
 
+// parse_struct_tag parses a property tag, its arguments are strings or names
+// such as @json("email_address", omitempty)
+func parse_struct_tag(parser *parser) ast.StructTag {
+	parser.expect(lexer.AT)
+	parser.advance(1)
+
+	tag := ast.StructTag{
+		Name:      parser.expect(lexer.IDENTIFIER).Value,
+		Arguments: make([]string, 0),
+	}
+	parser.advance(1)
+
+	if parser.current_token().Kind != lexer.OPEN_PAREN {
+		return tag
+	}
+	parser.advance(1)
+
+	for parser.current_token().Kind != lexer.CLOSE_PAREN {
+		argument := parser.current_token()
+		if !argument.IsOfKind(lexer.STRING, lexer.IDENTIFIER) {
+			panic(fmt.Errorf("expected a string or a name as argument of tag '%s' but got %s", tag.Name, argument.Kind))
+		}
+		tag.Arguments = append(tag.Arguments, argument.Value)
+		parser.advance(1)
+
+		if parser.current_token().Kind != lexer.CLOSE_PAREN {
+			parser.expect(lexer.COMMA)
+			parser.advance(1)
+		}
+	}
+	parser.advance(1)
+
+	return tag
+}
+
 func parse_struct_declaration_statement(parser *parser) ast.Statement {
 	parser.expect(lexer.STRUCT)
 	parser.advance(1)
@@ -222,6 +276,7 @@ func parse_struct_declaration_statement(parser *parser) ast.Statement {
 	parser.expect(lexer.OPEN_CURLY)
 	parser.advance(1)
 
+	embedded := make([]string, 0)
 	properties := make([]ast.StructProperty, 0)
 	methods := make([]ast.StructMethod, 0)
 	for !parser.is_empty() && parser.current_token().Kind != lexer.CLOSE_CURLY {
@@ -230,7 +285,12 @@ func parse_struct_declaration_statement(parser *parser) ast.Statement {
 			parser.advance(1)
 		}
 
-		if parser.current_token().Kind == lexer.FN {
+		// A bare struct name embeds that struct, e.g. struct Task { Entity; title: string }
+		if !isStatic && parser.current_token().Kind == lexer.IDENTIFIER &&
+			parser.next_token().IsOfKind(lexer.SEMI_COLON, lexer.CLOSE_CURLY) {
+			embedded = append(embedded, parser.current_token().Value)
+			parser.advance(1)
+		} else if parser.current_token().Kind == lexer.FN {
 			decl := parse_function_declaration_statement(parser).(ast.FunctionDeclarationStatment)
 			methods = append(methods, ast.StructMethod{IsStatic: isStatic, Declaration: decl})
 		} else {
@@ -240,6 +300,23 @@ func parse_struct_declaration_statement(parser *parser) ast.Statement {
 				parser.advance(1)
 			}
 
+			// required is only a modifier when followed by the property name
+			isRequired := false
+			if parser.current_token().Value == "required" && parser.next_token().Kind == lexer.IDENTIFIER {
+				isRequired = true
+				parser.advance(1)
+			}
+
+			// A validation hook precedes the property, e.g. validate(in_range) priority: number
+			var validator ast.Expression
+			if parser.current_token().Value == "validate" && parser.next_token().Kind == lexer.OPEN_PAREN {
+				parser.advance(2)
+				validator = parse_expression(parser, default_bp)
+
+				parser.expect(lexer.CLOSE_PAREN)
+				parser.advance(1)
+			}
+
 			propertyIdentifier := parser.expect(lexer.IDENTIFIER).Value
 			parser.advance(1)
 
@@ -255,16 +332,28 @@ func parse_struct_declaration_statement(parser *parser) ast.Statement {
 				defaultValue = parse_expression(parser, default_bp)
 			}
 
+			tags := make([]ast.StructTag, 0)
+			for parser.current_token().Kind == lexer.AT {
+				tags = append(tags, parse_struct_tag(parser))
+			}
+
 			if defaultValue == nil && explicitType == nil {
 				panic(fmt.Errorf("cannot declare property '%s' without a type and a default value", propertyIdentifier))
 			}
 
+			if isRequired && (defaultValue != nil || isStatic) {
+				panic(fmt.Errorf("required property '%s' cannot be static or have a default value", propertyIdentifier))
+			}
+
 			properties = append(properties, ast.StructProperty{
 				IsStatic:     isStatic,
 				Type:         explicitType,
 				DefaultValue: defaultValue,
 				Identifier:   propertyIdentifier,
+				Validator:    validator,
 				IsConst:      isConst,
+				IsRequired:   isRequired,
+				Tags:         tags,
 			})
 		}
 
@@ -278,6 +367,7 @@ func parse_struct_declaration_statement(parser *parser) ast.Statement {
 
 	return ast.StructDeclarationStatement{
 		Identifier: identifier,
+		Embedded:   embedded,
 		Properties: properties,
 		Methods:    methods,
 	}
@@ -301,7 +391,33 @@ func parse_function_declaration_statement(parser *parser) ast.Statement {
 	parser.expect(lexer.FN)
 	parser.advance(1)
 
-	identifier := parser.expect(lexer.IDENTIFIER)
+	// A method declares its receiver before the name, e.g. fn (self: *Task) run()
+	var receiver *ast.Parameter
+	if parser.current_token().Kind == lexer.OPEN_PAREN {
+		parser.advance(1)
+
+		receiverName := parser.expect(lexer.IDENTIFIER).Value
+		parser.advance(1)
+
+		parser.expect(lexer.COLON)
+		parser.advance(1)
+
+		receiver = &ast.Parameter{
+			Name: receiverName,
+			Type: parse_type(parser, default_bp),
+		}
+
+		parser.expect(lexer.CLOSE_PAREN)
+		parser.advance(1)
+	}
+
+	// A constructor is declared as a method named new
+	var identifier lexer.Token
+	if parser.current_token().Kind == lexer.NEW {
+		identifier = lexer.NewToken(lexer.IDENTIFIER, parser.current_token().Value)
+	} else {
+		identifier = parser.expect(lexer.IDENTIFIER)
+	}
 	parser.advance(1)
 
 	parser.expect(lexer.OPEN_PAREN)
@@ -363,6 +479,7 @@ func parse_function_declaration_statement(parser *parser) ast.Statement {
 
 	return ast.FunctionDeclarationStatment{
 		Identifier: identifier.Value,
+		Receiver:   receiver,
 		Parameters: params,
 		Body:       body,
 		ReturnType: return_type,
@@ -389,7 +506,22 @@ func parse_throw_statement(parser *parser) ast.Statement {
 	parser.expect(lexer.THROW)
 	parser.advance(1)
 
+	// A bare throw rethrows the exception handled by the enclosing catch clause
+	var value ast.Expression
+	if !parser.is_empty() && !parser.current_token().IsOfKind(lexer.SEMI_COLON, lexer.CLOSE_CURLY) {
+		value = parse_expression(parser, default_bp)
+	}
+
 	return ast.ThrowStatement{
+		Value: value,
+	}
+}
+
+func parse_defer_statement(parser *parser) ast.Statement {
+	parser.expect(lexer.DEFER)
+	parser.advance(1)
+
+	return ast.DeferStatement{
 		Value: parse_expression(parser, default_bp),
 	}
 }